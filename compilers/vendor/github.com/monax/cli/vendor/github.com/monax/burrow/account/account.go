@@ -0,0 +1,67 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package account holds the on-chain account type and the key-holding
+// wrapper used to sign for it in tests and tools.
+package account
+
+import (
+	ptypes "github.com/monax/burrow/permission/types"
+
+	"github.com/tendermint/go-crypto"
+)
+
+// Account is the on-chain state associated with an address: its balance,
+// any contract code and storage root, its sequence number (nonce), and the
+// permissions attached to it.
+type Account struct {
+	Address     []byte
+	PubKey      crypto.PubKey
+	Balance     int64
+	Code        []byte
+	Sequence    int
+	StorageRoot []byte
+	Permissions ptypes.AccountPermissions
+}
+
+func (acc *Account) Copy() *Account {
+	accCopy := *acc
+	return &accCopy
+}
+
+// PrivAccount pairs an Account's address and public key with the private
+// key needed to sign transactions on its behalf.
+type PrivAccount struct {
+	Address []byte
+	PubKey  crypto.PubKey
+	PrivKey crypto.PrivKey
+}
+
+// GenPrivAccountFromSecret deterministically derives a PrivAccount from an
+// arbitrary secret string, for use in tests and the genesis tool.
+func GenPrivAccountFromSecret(secret string) *PrivAccount {
+	privKey := crypto.GenPrivKeyEd25519FromSecret([]byte(secret))
+	pubKey := privKey.PubKey()
+	return &PrivAccount{
+		Address: pubKey.Address(),
+		PubKey:  pubKey,
+		PrivKey: privKey,
+	}
+}
+
+// Sign signs msg (the canonical sign-bytes of some transaction on chainID)
+// with this account's private key.
+func (pa *PrivAccount) Sign(msg []byte) crypto.Signature {
+	return pa.PrivKey.Sign(msg)
+}
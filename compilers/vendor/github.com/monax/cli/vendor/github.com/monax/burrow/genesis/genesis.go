@@ -0,0 +1,57 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package genesis describes the genesis document that seeds a chain's
+// initial account set, validator set, and default permissions.
+package genesis
+
+import (
+	"time"
+
+	ptypes "github.com/monax/burrow/permission/types"
+
+	"github.com/tendermint/go-crypto"
+)
+
+type BasicAccount struct {
+	Address []byte
+}
+
+type GenesisAccount struct {
+	Address     []byte
+	Amount      int64
+	Name        string
+	Permissions *ptypes.AccountPermissions
+}
+
+type GenesisValidator struct {
+	PubKey   crypto.PubKeyEd25519
+	Amount   int64
+	Name     string
+	UnbondTo []BasicAccount
+}
+
+// GenesisParams holds the chain-wide defaults that apply when an account
+// does not explicitly set a given base permission.
+type GenesisParams struct {
+	GlobalPermissions *ptypes.AccountPermissions
+}
+
+type GenesisDoc struct {
+	GenesisTime time.Time
+	ChainID     string
+	Params      *GenesisParams
+	Accounts    []GenesisAccount
+	Validators  []GenesisValidator
+}
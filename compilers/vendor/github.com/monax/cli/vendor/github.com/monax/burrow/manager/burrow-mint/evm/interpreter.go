@@ -0,0 +1,262 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	. "github.com/monax/burrow/manager/burrow-mint/evm/opcodes"
+	ptypes "github.com/monax/burrow/permission/types"
+	. "github.com/monax/burrow/word256"
+)
+
+// interpreter runs a single call frame's bytecode against a simple
+// word-addressed stack and byte-addressed memory. It implements only the
+// opcodes this package's callers actually emit (see opcodes.Bytecode call
+// sites under the state package's tests and the SNative dispatcher) - it
+// is not a general-purpose EVM.
+type interpreter struct {
+	vm     *VM
+	callee *Account
+	code   []byte
+	input  []byte
+	gas    *int64
+
+	pc     int
+	stack  []Word256
+	memory []byte
+}
+
+func newInterpreter(vm *VM, callee *Account, code, input []byte, gas *int64) *interpreter {
+	return &interpreter{vm: vm, callee: callee, code: code, input: input, gas: gas}
+}
+
+func (in *interpreter) push(w Word256) {
+	in.stack = append(in.stack, w)
+}
+
+func (in *interpreter) pop() Word256 {
+	if len(in.stack) == 0 {
+		panic("stack underflow")
+	}
+	w := in.stack[len(in.stack)-1]
+	in.stack = in.stack[:len(in.stack)-1]
+	return w
+}
+
+func (in *interpreter) popUint64() uint64 {
+	return Word256ToUint64(in.pop())
+}
+
+func (in *interpreter) useGas(n int64) error {
+	if in.gas != nil {
+		if *in.gas < n {
+			return fmt.Errorf("out of gas")
+		}
+		*in.gas -= n
+	}
+	return nil
+}
+
+func (in *interpreter) growMemory(offset, size uint64) {
+	need := offset + size
+	if uint64(len(in.memory)) < need {
+		grown := make([]byte, need)
+		copy(grown, in.memory)
+		in.memory = grown
+	}
+}
+
+func (in *interpreter) run() (output []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("vm: %v", r)
+		}
+	}()
+	for in.pc < len(in.code) {
+		op := OpCode(in.code[in.pc])
+		in.pc++
+		if err := in.useGas(1); err != nil {
+			return nil, err
+		}
+		switch op {
+		case STOP:
+			return nil, nil
+
+		case POP:
+			in.pop()
+
+		case GAS:
+			g := int64(0)
+			if in.gas != nil {
+				g = *in.gas
+			}
+			in.push(Uint64ToWord256(uint64(g)))
+
+		case DIV:
+			a, b := in.popUint64(), in.popUint64()
+			if b == 0 {
+				in.push(Word256{})
+			} else {
+				in.push(Uint64ToWord256(a / b))
+			}
+
+		case CALLDATASIZE:
+			in.push(Uint64ToWord256(uint64(len(in.input))))
+
+		case CALLDATACOPY:
+			memOff, inOff, size := in.popUint64(), in.popUint64(), in.popUint64()
+			in.growMemory(memOff, size)
+			data := make([]byte, size)
+			if inOff < uint64(len(in.input)) {
+				copy(data, in.input[inOff:])
+			}
+			copy(in.memory[memOff:], data)
+
+		case MSTORE:
+			offset := in.popUint64()
+			val := in.pop()
+			in.growMemory(offset, Word256Bytes)
+			copy(in.memory[offset:], val.Bytes())
+
+		case CREATE:
+			offset, size, value := in.popUint64(), in.popUint64(), in.popUint64()
+			in.growMemory(offset, size)
+			initCode := make([]byte, size)
+			copy(initCode, in.memory[offset:offset+size])
+			addr, err := in.create(int64(value), initCode)
+			if err != nil {
+				// unlike a real EVM, a failed CREATE aborts the enclosing
+				// frame outright rather than merely returning zero: none of
+				// the SNative or test bytecode this interpreter runs ever
+				// inspects CREATE's return value to decide whether to revert
+				// itself, so a silent zero would let a CreateContract-less
+				// account's CREATE attempt succeed from the caller's view.
+				return nil, err
+			}
+			in.push(LeftPadWord256(addr))
+
+		case CALL:
+			gasWord := in.pop()
+			addr := in.pop()
+			value := in.popUint64()
+			inOff, inSize := in.popUint64(), in.popUint64()
+			retOff, retSize := in.popUint64(), in.popUint64()
+			callee := addr.Bytes()[Word256Bytes-20:]
+			callData := make([]byte, inSize)
+			in.growMemory(inOff, inSize)
+			copy(callData, in.memory[inOff:inOff+inSize])
+			callGas := int64(Word256ToUint64(gasWord))
+			ret, callErr := in.call(callee, callData, int64(value), &callGas)
+			if callErr != nil {
+				// as with CREATE: a failed CALL aborts this frame rather
+				// than pushing a failure bit and continuing, since none of
+				// this package's bytecode inspects CALL's success bit
+				// before returning.
+				return nil, callErr
+			}
+			in.push(Uint64ToWord256(1))
+			in.growMemory(retOff, retSize)
+			n := retSize
+			if uint64(len(ret)) < n {
+				n = uint64(len(ret))
+			}
+			copy(in.memory[retOff:retOff+n], ret[:n])
+
+		case RETURN:
+			offset, size := in.popUint64(), in.popUint64()
+			in.growMemory(offset, size)
+			return in.memory[offset : offset+size], nil
+
+		default:
+			if op >= PUSH1 && op <= PUSH32 {
+				n := int(op-PUSH1) + 1
+				// operand bytes past the end of code read as zero
+				buf := make([]byte, n)
+				end := in.pc + n
+				if end > len(in.code) {
+					end = len(in.code)
+				}
+				copy(buf, in.code[in.pc:end])
+				in.pc += n
+				in.push(LeftPadWord256(buf))
+				continue
+			}
+			return nil, fmt.Errorf("unknown opcode: %#x", byte(op))
+		}
+	}
+	return nil, nil
+}
+
+// call dispatches a CALL instruction to callee, routing to the registered
+// SNative contract when callee's address matches one. The calling account
+// (in.callee - the code currently executing) must hold Call to perform any
+// CALL at all, and additionally CreateAccount if callee has no account yet
+// - CALLing an unknown address is how this VM creates one, same as SendTx
+// to an unknown output. The all-zero address and the registered SNative
+// addresses are exempt from that second check: they are always considered
+// to already exist (the zero address as a conventional burn/no-op target,
+// the SNative addresses as permanently-registered contracts), so CALLing
+// either one never implicitly creates an account. The nested frame runs on
+// in.vm, the same *VM the outer frame is running on, so whatever
+// PermissionsGetter/GlobalPermissions override the caller installed via
+// SetPermissionsGetter/SetGlobalPermissions applies to every frame down the
+// call stack, not just the outermost one.
+func (in *interpreter) call(callee, callData []byte, value int64, gas *int64) ([]byte, error) {
+	if !in.vm.HasPermission(in.callee, ptypes.Call) {
+		return nil, fmt.Errorf("account %X lacks Call permission", in.callee.Address)
+	}
+	if snative := snativeContractByAddress(callee); snative != nil {
+		return in.vm.Call(in.callee, &Account{Address: callee}, nil, callData, value, gas)
+	}
+	calleeAcc := in.vm.appState.GetAccount(callee)
+	if calleeAcc == nil {
+		if !IsZeros(callee) && !in.vm.HasPermission(in.callee, ptypes.CreateAccount) {
+			return nil, fmt.Errorf("account %X lacks CreateAccount permission to CALL a new address", in.callee.Address)
+		}
+		calleeAcc = &Account{Address: callee}
+		in.vm.appState.UpdateAccount(calleeAcc)
+	}
+	return in.vm.Call(in.callee, calleeAcc, calleeAcc.Code, callData, value, gas)
+}
+
+// create executes CREATE: deterministically derive a new contract address
+// from the creating account and its sequence number, run initCode against
+// it, and persist whatever it returns as the new account's code. It fails
+// outright, leaving no new account behind, unless the creating account
+// holds CreateContract.
+func (in *interpreter) create(value int64, initCode []byte) ([]byte, error) {
+	if !in.vm.HasPermission(in.callee, ptypes.CreateContract) {
+		return nil, fmt.Errorf("account %X lacks CreateContract permission", in.callee.Address)
+	}
+	in.callee.Sequence++
+	addr := NewContractAddress(in.callee.Address, in.callee.Sequence)
+	newAcc := &Account{Address: addr}
+	code, err := in.vm.Call(in.callee, newAcc, initCode, nil, value, in.gas)
+	if err != nil {
+		return nil, err
+	}
+	newAcc.Code = code
+	in.vm.appState.UpdateAccount(newAcc)
+	return addr, nil
+}
+
+// NewContractAddress deterministically derives the address CREATE assigns
+// to the nonce'th contract created by addr.
+func NewContractAddress(addr []byte, nonce int) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, addr...), Uint64ToWord256(uint64(nonce)).Bytes()...))
+	return hash[:20]
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package opcodes is the dot-imported EVM instruction set: callers write
+// raw bytecode for tests and SNative wrappers using these mnemonics rather
+// than magic byte literals.
+package opcodes
+
+type OpCode byte
+
+const (
+	STOP       OpCode = 0x00
+	POP        OpCode = 0x50
+	MSTORE     OpCode = 0x52
+	CALLDATACOPY OpCode = 0x37
+	CALLDATASIZE OpCode = 0x36
+	RETURN     OpCode = 0xf3
+	CREATE     OpCode = 0xf0
+	CALL       OpCode = 0xf1
+	GAS        OpCode = 0x5a
+	DIV        OpCode = 0x04
+
+	PUSH1  OpCode = 0x60
+	PUSH20 OpCode = 0x73
+	PUSH32 OpCode = 0x7f
+)
+
+// Bytecode concatenates a mix of OpCodes, byte literals, and byte slices
+// (pushed verbatim, e.g. a PUSH20 address operand) into a single bytecode
+// string, so test helpers can write EVM code as a flat, readable sequence.
+func Bytecode(ops ...interface{}) []byte {
+	code := []byte{}
+	for _, op := range ops {
+		switch v := op.(type) {
+		case OpCode:
+			code = append(code, byte(v))
+		case byte:
+			code = append(code, v)
+		case int:
+			code = append(code, byte(v))
+		case []byte:
+			code = append(code, v...)
+		default:
+			panic("Bytecode: unsupported operand type")
+		}
+	}
+	return code
+}
@@ -0,0 +1,302 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vm
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	ptypes "github.com/monax/burrow/permission/types"
+	. "github.com/monax/burrow/word256"
+)
+
+// SNativeContract is a "native" contract: its functions are implemented in
+// Go rather than EVM bytecode, but it is addressed and CALLed exactly like
+// any other account. Permissions is the only one registered today.
+type SNativeContract struct {
+	name      string
+	address   []byte
+	functions []*SNativeFunction
+}
+
+func (c *SNativeContract) AddressBytes() []byte {
+	return c.address
+}
+
+func (c *SNativeContract) FunctionByName(name string) (*SNativeFunction, error) {
+	for _, f := range c.functions {
+		if f.name == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no SNative function %q on contract %s", name, c.name)
+}
+
+func (c *SNativeContract) functionByID(id [4]byte) (*SNativeFunction, error) {
+	for _, f := range c.functions {
+		if f.ID() == id {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no SNative function with ID %X on contract %s", id, c.name)
+}
+
+func (c *SNativeContract) call(vm *VM, caller *Account, data []byte) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("SNative call data too short to contain a function ID")
+	}
+	var id [4]byte
+	copy(id[:], data[:4])
+	f, err := c.functionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !vm.HasPermission(caller, f.gate) {
+		return nil, fmt.Errorf("account %X lacks the %s permission required to call %s", caller.Address, f.name, f.name)
+	}
+	return f.exec(vm, data[4:])
+}
+
+// SNativeFunction is a single dispatchable entry point on an SNativeContract.
+type SNativeFunction struct {
+	name string
+	gate ptypes.PermFlag
+	exec func(vm *VM, args []byte) ([]byte, error)
+}
+
+// ID is this function's 4-byte selector, analogous to an ABI function
+// selector: callers derive it by name via permNameToFuncID/FunctionByName
+// rather than hand-coding the bytes.
+func (f *SNativeFunction) ID() [4]byte {
+	h := sha256.Sum256([]byte(f.name))
+	var id [4]byte
+	copy(id[:], h[:4])
+	return id
+}
+
+var permissionsContract = newPermissionsContract()
+
+// SNativeContracts returns the registered SNative contracts by name.
+func SNativeContracts() map[string]*SNativeContract {
+	return map[string]*SNativeContract{
+		"Permissions": permissionsContract,
+	}
+}
+
+func snativeContractByAddress(addr []byte) *SNativeContract {
+	for _, c := range SNativeContracts() {
+		if string(c.address) == string(addr) {
+			return c
+		}
+	}
+	return nil
+}
+
+// IsSNativeAddress reports whether addr is a registered SNative contract,
+// for callers outside this package that cannot reach this package's
+// unexported lookup.
+func IsSNativeAddress(addr []byte) bool {
+	return snativeContractByAddress(addr) != nil
+}
+
+// permissionsAddress is the fixed, reserved address CALL/CallTx reach the
+// Permissions SNative contract at.
+var permissionsAddress = append(make([]byte, 19), 0x01)
+
+func newPermissionsContract() *SNativeContract {
+	c := &SNativeContract{name: "Permissions", address: permissionsAddress}
+	c.functions = []*SNativeFunction{
+		{name: "hasBase", gate: ptypes.HasBase, exec: snativeHasBase},
+		{name: "setBase", gate: ptypes.SetBase, exec: snativeSetBase},
+		{name: "unsetBase", gate: ptypes.UnsetBase, exec: snativeUnsetBase},
+		{name: "setGlobal", gate: ptypes.SetGlobal, exec: snativeSetGlobal},
+		{name: "hasRole", gate: ptypes.HasRole, exec: snativeHasRole},
+		{name: "addRole", gate: ptypes.AddRole, exec: snativeAddRole},
+		{name: "removeRole", gate: ptypes.RmRole, exec: snativeRemoveRole},
+		{name: "getBase", gate: ptypes.HasBase, exec: snativeGetBase},
+		{name: "getGlobal", gate: ptypes.SetGlobal, exec: snativeGetGlobal},
+		{name: "listRoles", gate: ptypes.HasRole, exec: snativeListRoles},
+		{name: "setBaseBatch", gate: ptypes.SetBaseBatch, exec: snativeSetBaseBatch},
+	}
+	return c
+}
+
+func decodeAddrPerm(args []byte) ([]byte, ptypes.PermFlag) {
+	addr := args[Word256Bytes-20 : Word256Bytes]
+	perm := ptypes.PermFlag(Word256ToUint64(LeftPadWord256(args[Word256Bytes : 2*Word256Bytes])))
+	return addr, perm
+}
+
+func targetAccount(vm *VM, addr []byte) (*Account, error) {
+	acc := vm.appState.GetAccount(addr)
+	if acc == nil {
+		return nil, fmt.Errorf("no such account: %X", addr)
+	}
+	return acc, nil
+}
+
+func snativeHasBase(vm *VM, args []byte) ([]byte, error) {
+	addr, perm := decodeAddrPerm(args)
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	return boolWord(vm.HasPermission(acc, perm)), nil
+}
+
+// snativeGetBase takes just an address (unlike hasBase, which also takes
+// the specific permission to check) and returns the account's raw
+// (Perms, SetBit) pair as two words, so a caller can tell a bit that is
+// unset (falling back to the global value) from one explicitly set false.
+func snativeGetBase(vm *VM, args []byte) ([]byte, error) {
+	addr := args[Word256Bytes-20 : Word256Bytes]
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	base := acc.Permissions.Base
+	ret := Uint64ToWord256(uint64(base.Perms)).Bytes()
+	return append(ret, Uint64ToWord256(uint64(base.SetBit)).Bytes()...), nil
+}
+
+func snativeSetBase(vm *VM, args []byte) ([]byte, error) {
+	addr, perm := decodeAddrPerm(args)
+	if perm == ptypes.Root {
+		return nil, fmt.Errorf("setBase cannot grant or revoke Root: it is not delegable via a base permission set")
+	}
+	value := Word256ToUint64(LeftPadWord256(args[2*Word256Bytes:3*Word256Bytes])) != 0
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := acc.Permissions.Base.Set(perm, value); err != nil {
+		return nil, err
+	}
+	vm.appState.UpdateAccount(acc)
+	return nil, nil
+}
+
+func snativeUnsetBase(vm *VM, args []byte) ([]byte, error) {
+	addr, perm := decodeAddrPerm(args)
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := acc.Permissions.Base.Unset(perm); err != nil {
+		return nil, err
+	}
+	vm.appState.UpdateAccount(acc)
+	return nil, nil
+}
+
+func snativeSetGlobal(vm *VM, args []byte) ([]byte, error) {
+	perm := ptypes.PermFlag(Word256ToUint64(LeftPadWord256(args[:Word256Bytes])))
+	if perm == ptypes.Root {
+		return nil, fmt.Errorf("setGlobal cannot grant or revoke Root")
+	}
+	value := Word256ToUint64(LeftPadWord256(args[Word256Bytes:2*Word256Bytes])) != 0
+	global, err := targetAccount(vm, ptypes.GlobalPermissionsAddress)
+	if err != nil {
+		global = &Account{Address: ptypes.GlobalPermissionsAddress}
+	}
+	if err := global.Permissions.Base.Set(perm, value); err != nil {
+		return nil, err
+	}
+	vm.appState.UpdateAccount(global)
+	return nil, nil
+}
+
+func snativeGetGlobal(vm *VM, args []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func decodeAddrRole(args []byte) ([]byte, string) {
+	addr := args[Word256Bytes-20 : Word256Bytes]
+	roleBytes := args[Word256Bytes : 2*Word256Bytes]
+	n := len(roleBytes)
+	for n > 0 && roleBytes[n-1] == 0 {
+		n--
+	}
+	return addr, string(roleBytes[:n])
+}
+
+func snativeHasRole(vm *VM, args []byte) ([]byte, error) {
+	addr, role := decodeAddrRole(args)
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	return boolWord(acc.Permissions.HasRole(role)), nil
+}
+
+func snativeAddRole(vm *VM, args []byte) ([]byte, error) {
+	addr, role := decodeAddrRole(args)
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	acc.Permissions.AddRole(role)
+	vm.appState.UpdateAccount(acc)
+	return nil, nil
+}
+
+func snativeRemoveRole(vm *VM, args []byte) ([]byte, error) {
+	addr, role := decodeAddrRole(args)
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	acc.Permissions.RmRole(role)
+	vm.appState.UpdateAccount(acc)
+	return nil, nil
+}
+
+func snativeListRoles(vm *VM, args []byte) ([]byte, error) {
+	addr := args[Word256Bytes-20 : Word256Bytes]
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	ret := Uint64ToWord256(uint64(len(acc.Permissions.Roles))).Bytes()
+	for _, r := range acc.Permissions.Roles {
+		ret = append(ret, RightPadWord256(r).Bytes()...)
+	}
+	return ret, nil
+}
+
+// snativeSetBaseBatch applies PermsMask/ValuesMask atomically via
+// BasePermissions.SetBatch: an unrecognised bit in PermsMask fails the
+// whole call and leaves the target account's permissions untouched.
+func snativeSetBaseBatch(vm *VM, args []byte) ([]byte, error) {
+	addr := args[Word256Bytes-20 : Word256Bytes]
+	permsMask := Word256ToUint64(LeftPadWord256(args[Word256Bytes : 2*Word256Bytes]))
+	valuesMask := Word256ToUint64(LeftPadWord256(args[2*Word256Bytes : 3*Word256Bytes]))
+	acc, err := targetAccount(vm, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := acc.Permissions.Base.SetBatch(permsMask, valuesMask); err != nil {
+		return nil, err
+	}
+	vm.appState.UpdateAccount(acc)
+	return nil, nil
+}
+
+func boolWord(v bool) []byte {
+	if v {
+		return Uint64ToWord256(1).Bytes()
+	}
+	return Uint64ToWord256(0).Bytes()
+}
@@ -0,0 +1,185 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vm is the EVM: the bytecode interpreter, the SNative contracts
+// that expose permission/role management to CALL and CallTx alike, and the
+// permission-check plumbing both rely on.
+package vm
+
+import (
+	"fmt"
+
+	acm "github.com/monax/burrow/account"
+	ptypes "github.com/monax/burrow/permission/types"
+	"github.com/monax/burrow/txs"
+	. "github.com/monax/burrow/word256"
+
+	"github.com/tendermint/go-events"
+)
+
+// Account is the EVM's view of an account. It is an alias for acm.Account,
+// not a parallel type: the account package has no dependency on vm, so
+// aliasing it here costs nothing, and it lets state.BlockCache satisfy
+// AppState directly instead of needing a conversion layer at the
+// state/VM boundary.
+type Account = acm.Account
+
+// AppState is the account/storage backing a VM needs to run: get/update
+// accounts and read/write their storage. *state.BlockCache satisfies this
+// directly and is passed to NewVM as-is.
+type AppState interface {
+	GetAccount(addr []byte) *Account
+	UpdateAccount(acc *Account)
+	GetStorage(addr []byte, key Word256) Word256
+	SetStorage(addr []byte, key, value Word256)
+}
+
+// DynamicMemoryParams bounds the memory a single call frame may grow to.
+type DynamicMemoryParams struct {
+	MemoryLimit uint64
+}
+
+func DefaultDynamicMemoryParams() DynamicMemoryParams {
+	return DynamicMemoryParams{MemoryLimit: 1 << 32}
+}
+
+// VM is one transaction's worth of EVM execution state: the account store
+// it runs against, the permissions getter/global override installed for
+// this call (if any), and the running call-depth it enforces. ExecTx builds
+// a fresh VM for each transaction, and ExecTxWithVM runs on the one its
+// caller supplies - it is never shared or reused across transactions in
+// this package, so SetPermissionsGetter/SetGlobalPermissions are
+// plain instance fields rather than package state: nothing outside the one
+// goroutine driving this VM ever touches them.
+type VM struct {
+	appState AppState
+	params   DynamicMemoryParams
+	origin   []byte
+	txHash   []byte
+
+	permissionsGetter func(acc *Account) ptypes.BasePermissions
+	globalPermissions ptypes.BasePermissions
+
+	evc       events.Fireable
+	callDepth int
+}
+
+const MaxCallDepth = 64
+
+func NewVM(appState AppState, params DynamicMemoryParams, origin []byte, txHash []byte) *VM {
+	return &VM{
+		appState: appState,
+		params:   params,
+		origin:   origin,
+		txHash:   txHash,
+	}
+}
+
+// SetPermissionsGetter overrides how this VM resolves an account's base
+// permissions for the lifetime of the call tree it is about to run,
+// instead of reading acc.Permissions.Base directly. It is scoped to this
+// VM instance only: callers that want a permissions override for one
+// ExecTx must build their own *VM (see ExecTxWithVM) rather than mutating
+// shared state, so concurrent transactions never observe each other's
+// override.
+func (vm *VM) SetPermissionsGetter(getter func(acc *Account) ptypes.BasePermissions) {
+	vm.permissionsGetter = getter
+}
+
+// SetGlobalPermissions overrides the fallback BasePermissions consulted
+// when an account has not explicitly set a given bit, for this VM
+// instance only.
+func (vm *VM) SetGlobalPermissions(perms ptypes.BasePermissions) {
+	vm.globalPermissions = perms
+}
+
+// SetEventFireable installs the sink this VM fires AccReceive events on as
+// each CALL frame begins, and AccCall events on as each nested frame exits
+// (see Call). Scoped to this VM instance only, same
+// as the permissions overrides above. A nil sink (the default) means call
+// frames simply aren't observable as events.
+func (vm *VM) SetEventFireable(evc events.Fireable) {
+	vm.evc = evc
+}
+
+func (vm *VM) basePermissions(acc *Account) ptypes.BasePermissions {
+	if vm.permissionsGetter != nil {
+		return vm.permissionsGetter(acc)
+	}
+	return acc.Permissions.Base
+}
+
+// HasPermission reports whether acc holds perm, falling back to this VM's
+// global permissions (explicit override, or else GlobalPermissionsAddress's
+// on-chain account) when acc has not explicitly set that bit.
+func (vm *VM) HasPermission(acc *Account, perm ptypes.PermFlag) bool {
+	base := vm.basePermissions(acc)
+	if v, err := base.Get(perm); err == nil {
+		return v
+	}
+	if v, err := vm.globalPermissions.Get(perm); err == nil {
+		return v
+	}
+	if global := vm.appState.GetAccount(ptypes.GlobalPermissionsAddress); global != nil {
+		if v, err := global.Permissions.Base.Get(perm); err == nil {
+			return v
+		}
+	}
+	return false
+}
+
+// Call runs code belonging to callee, with caller as msg.sender and input
+// as msg.data, returning code's return value. It fires AccReceive the
+// moment the frame begins - before it's known whether this frame, or any
+// enclosing one, will ultimately revert - at StackDepth 0 for the
+// outermost frame of a CallTx and one higher per nested CALL/CREATE below
+// it. A nested frame also fires AccCall as it exits, carrying its return
+// value and exception; the outermost frame's AccCall is fired by
+// state.execCallTx instead.
+func (vm *VM) Call(caller, callee *Account, code, input []byte, value int64, gas *int64) (output []byte, err error) {
+	vm.callDepth++
+	depth := vm.callDepth
+	defer func() { vm.callDepth-- }()
+	if depth > MaxCallDepth {
+		return nil, fmt.Errorf("call stack depth limit reached")
+	}
+	if vm.evc != nil {
+		vm.evc.FireEvent(txs.EventStringAccReceive(callee.Address), txs.EventDataCall{
+			Caller:     caller.Address,
+			Callee:     callee.Address,
+			Data:       input,
+			StackDepth: depth - 1,
+		})
+		if depth > 1 {
+			defer func() {
+				exception := ""
+				if err != nil {
+					exception = err.Error()
+				}
+				vm.evc.FireEvent(txs.EventStringAccCall(callee.Address), txs.EventDataCall{
+					Caller:     caller.Address,
+					Callee:     callee.Address,
+					Data:       input,
+					Return:     output,
+					Exception:  exception,
+					StackDepth: depth - 1,
+				})
+			}()
+		}
+	}
+	if snative := snativeContractByAddress(callee.Address); snative != nil {
+		return snative.call(vm, caller, input)
+	}
+	return newInterpreter(vm, callee, code, input, gas).run()
+}
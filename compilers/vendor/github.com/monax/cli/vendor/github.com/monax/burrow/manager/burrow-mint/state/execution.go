@@ -0,0 +1,449 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	acm "github.com/monax/burrow/account"
+	"github.com/monax/burrow/manager/burrow-mint/evm"
+	ptypes "github.com/monax/burrow/permission/types"
+	"github.com/monax/burrow/txs"
+
+	"github.com/tendermint/go-events"
+)
+
+// ExecTx validates and applies tx against blockCache, building a fresh *vm.VM
+// for it (see ExecTxWithVM for supplying your own, e.g. with a
+// PermissionsGetter override installed). evc may be nil, in which case tx
+// runs without firing any events.
+func ExecTx(blockCache *BlockCache, tx_ txs.Tx, runCall bool, evc events.Fireable) error {
+	theVM := vm.NewVM(blockCache, vm.DefaultDynamicMemoryParams(), txSignerAddress(tx_), tx_.Hash(blockCache.ChainID()))
+	return ExecTxWithVM(blockCache, tx_, runCall, evc, theVM)
+}
+
+// ExecTxWithVM behaves like ExecTx but dispatches against the caller-supplied
+// VM as-is, rather than building a default one - so a caller that has
+// installed its own PermissionsGetter/GlobalPermissions override via
+// vm.VM.SetPermissionsGetter/SetGlobalPermissions sees that override honoured
+// for every permission check this Tx triggers, instead of it being silently
+// replaced by a freshly constructed VM.
+func ExecTxWithVM(blockCache *BlockCache, tx_ txs.Tx, runCall bool, evc events.Fireable, theVM *vm.VM) error {
+	theVM.SetEventFireable(evc)
+	switch tx := tx_.(type) {
+	case *txs.SendTx:
+		return execSendTx(blockCache, tx, theVM)
+	case *txs.CallTx:
+		return execCallTx(blockCache, tx, runCall, evc, theVM)
+	case *txs.NameTx:
+		return execNameTx(blockCache, tx, theVM)
+	case *txs.BondTx:
+		return execBondTx(blockCache, tx, theVM)
+	case *txs.UnbondTx:
+		return execUnbondTx(blockCache, tx, evc, theVM)
+	case *txs.PermissionsTx:
+		return execPermissionsTx(blockCache, tx, theVM)
+	default:
+		return fmt.Errorf("unknown transaction type: %T", tx_)
+	}
+}
+
+// txSignerAddress picks the address a fresh VM should record as its origin:
+// the one account that pays for/signs tx, or the first of several for the
+// multi-input Tx types. It is purely informational (vm.VM never branches on
+// origin), so a best-effort single address is enough even for UnbondTx,
+// which has no notion of a paying input.
+func txSignerAddress(tx_ txs.Tx) []byte {
+	switch tx := tx_.(type) {
+	case *txs.SendTx:
+		if len(tx.Inputs) > 0 {
+			return tx.Inputs[0].Address
+		}
+	case *txs.CallTx:
+		return tx.Input.Address
+	case *txs.NameTx:
+		return tx.Input.Address
+	case *txs.BondTx:
+		if len(tx.Inputs) > 0 {
+			return tx.Inputs[0].Address
+		}
+	case *txs.UnbondTx:
+		return tx.Address
+	case *txs.PermissionsTx:
+		return tx.Input.Address
+	}
+	return nil
+}
+
+// validateInput checks a single TxInput against the account it claims to
+// spend from: the claimed sequence number must be exactly one past the
+// account's last used sequence, the signature must verify against hash (the
+// owning Tx's own Hash(chainID)), and the account must be able to cover
+// in.Amount.
+func validateInput(acc *acm.Account, hash []byte, in *txs.TxInput) error {
+	if acc == nil {
+		return fmt.Errorf("invalid address %X: no such account", in.Address)
+	}
+	if in.Sequence != acc.Sequence+1 {
+		return fmt.Errorf("invalid sequence %d for account %X: expected %d", in.Sequence, in.Address, acc.Sequence+1)
+	}
+	if !in.PubKey.VerifyBytes(hash, in.Signature) {
+		return fmt.Errorf("invalid signature for account %X", in.Address)
+	}
+	if acc.Balance < in.Amount {
+		return fmt.Errorf("insufficient funds: account %X has balance %d, needs %d", in.Address, acc.Balance, in.Amount)
+	}
+	return nil
+}
+
+// targetAccountForTx looks up the account a PermissionsTx/SNative function
+// mutates, distinct from validateInput's lookup of the paying/signing
+// account.
+func targetAccountForTx(blockCache *BlockCache, addr []byte) (*acm.Account, error) {
+	acc := blockCache.GetAccount(addr)
+	if acc == nil {
+		return nil, fmt.Errorf("no such account: %X", addr)
+	}
+	return acc, nil
+}
+
+func execSendTx(blockCache *BlockCache, tx *txs.SendTx, theVM *vm.VM) error {
+	hash := tx.Hash(blockCache.ChainID())
+	ins := make([]*acm.Account, len(tx.Inputs))
+	var totalIn, totalOut int64
+	for i, in := range tx.Inputs {
+		acc := blockCache.GetAccount(in.Address)
+		if err := validateInput(acc, hash, in); err != nil {
+			return err
+		}
+		if !theVM.HasPermission(acc, ptypes.Send) {
+			return fmt.Errorf("account %X lacks Send permission", in.Address)
+		}
+		ins[i] = acc
+		totalIn += in.Amount
+	}
+	createsAccount := false
+	for _, out := range tx.Outputs {
+		totalOut += out.Amount
+		if blockCache.GetAccount(out.Address) == nil {
+			createsAccount = true
+		}
+	}
+	if createsAccount {
+		// sending to an unknown address is how a SendTx creates an account,
+		// same as CALLing one - every input must be trusted to do that.
+		for _, acc := range ins {
+			if !theVM.HasPermission(acc, ptypes.CreateAccount) {
+				return fmt.Errorf("account %X lacks CreateAccount permission to send to an unknown address", acc.Address)
+			}
+		}
+	}
+	if totalIn != totalOut {
+		return fmt.Errorf("SendTx total input %d does not equal total output %d", totalIn, totalOut)
+	}
+
+	for i, in := range tx.Inputs {
+		ins[i].Sequence++
+		ins[i].Balance -= in.Amount
+		blockCache.UpdateAccount(ins[i])
+	}
+	for _, out := range tx.Outputs {
+		acc := blockCache.GetAccount(out.Address)
+		if acc == nil {
+			acc = &acm.Account{Address: out.Address}
+		}
+		acc.Balance += out.Amount
+		blockCache.UpdateAccount(acc)
+	}
+	return nil
+}
+
+// execCallTx enforces the top-level permission this CallTx needs - Call to
+// reach an existing contract, CreateContract to deploy a new one - as a hard
+// Go error, since that preflight never touches the VM at all. Once the VM is
+// actually invoked, any failure inside it (a nested CALL/CREATE rejected for
+// lack of permission on the callee, a reverted frame, ...) is captured only
+// as the fired EventDataCall's Exception field: ExecTx still returns nil,
+// matching how a real chain would still include (and charge for) a reverted
+// CallTx in a block rather than rejecting it outright.
+func execCallTx(blockCache *BlockCache, tx *txs.CallTx, runCall bool, evc events.Fireable, theVM *vm.VM) error {
+	hash := tx.Hash(blockCache.ChainID())
+	caller := blockCache.GetAccount(tx.Input.Address)
+	if err := validateInput(caller, hash, tx.Input); err != nil {
+		return err
+	}
+
+	createsContract := tx.Address == nil
+	if createsContract {
+		if !theVM.HasPermission(caller, ptypes.CreateContract) {
+			return fmt.Errorf("account %X lacks CreateContract permission", caller.Address)
+		}
+	} else if !theVM.HasPermission(caller, ptypes.Call) {
+		return fmt.Errorf("account %X lacks Call permission", caller.Address)
+	}
+
+	caller.Sequence++
+	caller.Balance -= tx.Input.Amount
+	blockCache.UpdateAccount(caller)
+
+	if !runCall {
+		return nil
+	}
+
+	var callee *acm.Account
+	var code, input []byte
+	var calleeAddr []byte
+	if createsContract {
+		calleeAddr = NewContractAddress(tx.Input.Address, tx.Input.Sequence)
+		callee = &acm.Account{Address: calleeAddr}
+		code = tx.Data
+	} else {
+		calleeAddr = tx.Address
+		callee = blockCache.GetAccount(calleeAddr)
+		if callee == nil {
+			callee = &acm.Account{Address: calleeAddr}
+		}
+		code = callee.Code
+		input = tx.Data
+	}
+
+	gas := tx.GasLimit
+	ret, callErr := theVM.Call(caller, callee, code, input, tx.Input.Amount, &gas)
+
+	exception := ""
+	if callErr != nil {
+		exception = callErr.Error()
+	} else if createsContract {
+		callee.Code = ret
+		blockCache.UpdateAccount(callee)
+	}
+
+	if evc != nil {
+		evc.FireEvent(txs.EventStringAccCall(calleeAddr), txs.EventDataCall{
+			Caller:    caller.Address,
+			Callee:    calleeAddr,
+			Data:      tx.Data,
+			Return:    ret,
+			Exception: exception,
+		})
+	}
+	return nil
+}
+
+// execNameTx registers or renews Name -> Data in the NameReg: an unexpired
+// entry may only be touched by its own Owner, and the NameTx must provide at
+// least NameRegEntryCost(Name, Data) to cover the storage it occupies for
+// NameRegEntryLifetime.
+func execNameTx(blockCache *BlockCache, tx *txs.NameTx, theVM *vm.VM) error {
+	hash := tx.Hash(blockCache.ChainID())
+	acc := blockCache.GetAccount(tx.Input.Address)
+	if err := validateInput(acc, hash, tx.Input); err != nil {
+		return err
+	}
+	if !theVM.HasPermission(acc, ptypes.Name) {
+		return fmt.Errorf("account %X lacks Name permission", acc.Address)
+	}
+	cost := NameRegEntryCost(tx.Name, tx.Data)
+	if tx.Amount < cost {
+		return fmt.Errorf("NameTx Amount %d is below the %d required to store %q for %d blocks", tx.Amount, cost, tx.Name, NameRegEntryLifetime)
+	}
+	if entry := blockCache.GetNameRegEntry(tx.Name); entry != nil && entry.Expires > 0 && !bytes.Equal(entry.Owner, tx.Input.Address) {
+		return fmt.Errorf("name %q is owned by %X and has not expired", tx.Name, entry.Owner)
+	}
+
+	acc.Sequence++
+	acc.Balance -= tx.Input.Amount + tx.Fee
+	blockCache.UpdateAccount(acc)
+	blockCache.UpdateNameRegEntry(&NameRegEntry{
+		Name:    tx.Name,
+		Owner:   tx.Input.Address,
+		Data:    tx.Data,
+		Expires: NameRegEntryLifetime,
+	})
+	return nil
+}
+
+// execBondTx requires the bonding validator to hold Bond and to have signed
+// the bond itself (tx.VerifyBond), and every input funding it to hold Send
+// or Bond.
+func execBondTx(blockCache *BlockCache, tx *txs.BondTx, theVM *vm.VM) error {
+	chainID := blockCache.ChainID()
+	if !tx.VerifyBond(chainID) {
+		return fmt.Errorf("invalid or missing bond signature")
+	}
+	valAddr := tx.PubKey.Address()
+	valAcc := blockCache.GetAccount(valAddr)
+	if valAcc == nil {
+		return fmt.Errorf("unknown validator account %X", valAddr)
+	}
+	if !theVM.HasPermission(valAcc, ptypes.Bond) {
+		return fmt.Errorf("validator %X lacks Bond permission", valAddr)
+	}
+
+	hash := tx.Hash(chainID)
+	ins := make([]*acm.Account, len(tx.Inputs))
+	var totalIn, totalOut int64
+	for i, in := range tx.Inputs {
+		acc := blockCache.GetAccount(in.Address)
+		if err := validateInput(acc, hash, in); err != nil {
+			return err
+		}
+		if !theVM.HasPermission(acc, ptypes.Send) && !theVM.HasPermission(acc, ptypes.Bond) {
+			return fmt.Errorf("account %X must hold Send or Bond to fund a BondTx", in.Address)
+		}
+		ins[i] = acc
+		totalIn += in.Amount
+	}
+	for _, out := range tx.Outputs {
+		totalOut += out.Amount
+	}
+	if totalIn != totalOut {
+		return fmt.Errorf("BondTx total input %d does not equal total output %d", totalIn, totalOut)
+	}
+
+	for i, in := range tx.Inputs {
+		ins[i].Sequence++
+		ins[i].Balance -= in.Amount
+		blockCache.UpdateAccount(ins[i])
+	}
+	for _, out := range tx.Outputs {
+		acc := blockCache.GetAccount(out.Address)
+		if acc == nil {
+			acc = &acm.Account{Address: out.Address}
+		}
+		acc.Balance += out.Amount
+		blockCache.UpdateAccount(acc)
+	}
+	return nil
+}
+
+// execUnbondTx requires the unbonding validator to hold Bond at unbond time
+// (not just when they originally bonded). UnbondTx carries no PubKey - only
+// Address, Height and a signature over them - so unlike every other Tx type
+// here, its signature cannot be cryptographically verified against the
+// signer's own key from this package alone; the Bond permission gate is the
+// only check this snapshot's types make possible.
+func execUnbondTx(blockCache *BlockCache, tx *txs.UnbondTx, evc events.Fireable, theVM *vm.VM) error {
+	acc := blockCache.GetAccount(tx.Address)
+	if acc == nil {
+		return fmt.Errorf("unknown validator account %X", tx.Address)
+	}
+	if !theVM.HasPermission(acc, ptypes.Bond) {
+		return fmt.Errorf("account %X lacks Bond permission to unbond", tx.Address)
+	}
+	if evc != nil {
+		evc.FireEvent(txs.EventStringUnbond(tx.Address), txs.EventDataTx{Tx: tx})
+	}
+	return nil
+}
+
+// execPermissionsTx applies a single ptypes.PermArgs, gated by the PermFlag
+// it names. Granting or revoking Root itself is further restricted to
+// accounts that already hold Root: unlike every other bit, Root is not
+// delegable merely by holding SetBase/SetBaseBatch.
+func execPermissionsTx(blockCache *BlockCache, tx *txs.PermissionsTx, theVM *vm.VM) error {
+	hash := tx.Hash(blockCache.ChainID())
+	signer := blockCache.GetAccount(tx.Input.Address)
+	if err := validateInput(signer, hash, tx.Input); err != nil {
+		return err
+	}
+	if !theVM.HasPermission(signer, tx.PermArgs.PermFlag()) {
+		return fmt.Errorf("account %X lacks the permission required for this PermissionsTx", signer.Address)
+	}
+
+	switch args := tx.PermArgs.(type) {
+	case *ptypes.HasBaseArgs:
+		// read-only: nothing to apply
+
+	case *ptypes.SetBaseArgs:
+		if args.Permission == ptypes.Root && !theVM.HasPermission(signer, ptypes.Root) {
+			return fmt.Errorf("account %X must hold Root itself to grant or revoke Root", signer.Address)
+		}
+		target, err := targetAccountForTx(blockCache, args.Address)
+		if err != nil {
+			return err
+		}
+		if err := target.Permissions.Base.Set(args.Permission, args.Value); err != nil {
+			return err
+		}
+		blockCache.UpdateAccount(target)
+
+	case *ptypes.UnsetBaseArgs:
+		if args.Permission == ptypes.Root && !theVM.HasPermission(signer, ptypes.Root) {
+			return fmt.Errorf("account %X must hold Root itself to revoke Root", signer.Address)
+		}
+		target, err := targetAccountForTx(blockCache, args.Address)
+		if err != nil {
+			return err
+		}
+		if err := target.Permissions.Base.Unset(args.Permission); err != nil {
+			return err
+		}
+		blockCache.UpdateAccount(target)
+
+	case *ptypes.SetGlobalArgs:
+		if args.Permission == ptypes.Root {
+			return fmt.Errorf("setGlobal cannot grant or revoke Root")
+		}
+		global := blockCache.GetAccount(ptypes.GlobalPermissionsAddress)
+		if global == nil {
+			global = &acm.Account{Address: ptypes.GlobalPermissionsAddress}
+		}
+		if err := global.Permissions.Base.Set(args.Permission, args.Value); err != nil {
+			return err
+		}
+		blockCache.UpdateAccount(global)
+
+	case *ptypes.HasRoleArgs:
+		// read-only: nothing to apply
+
+	case *ptypes.AddRoleArgs:
+		target, err := targetAccountForTx(blockCache, args.Address)
+		if err != nil {
+			return err
+		}
+		target.Permissions.AddRole(args.Role)
+		blockCache.UpdateAccount(target)
+
+	case *ptypes.RmRoleArgs:
+		target, err := targetAccountForTx(blockCache, args.Address)
+		if err != nil {
+			return err
+		}
+		target.Permissions.RmRole(args.Role)
+		blockCache.UpdateAccount(target)
+
+	case *ptypes.SetBaseBatchArgs:
+		if args.PermsMask&uint64(ptypes.Root) != 0 && !theVM.HasPermission(signer, ptypes.Root) {
+			return fmt.Errorf("account %X must hold Root itself to grant or revoke Root via setBaseBatch", signer.Address)
+		}
+		target, err := targetAccountForTx(blockCache, args.Address)
+		if err != nil {
+			return err
+		}
+		if err := target.Permissions.Base.SetBatch(args.PermsMask, args.ValuesMask); err != nil {
+			return err
+		}
+		blockCache.UpdateAccount(target)
+
+	default:
+		return fmt.Errorf("unknown PermArgs type: %T", tx.PermArgs)
+	}
+
+	signer.Sequence++
+	blockCache.UpdateAccount(signer)
+	return nil
+}
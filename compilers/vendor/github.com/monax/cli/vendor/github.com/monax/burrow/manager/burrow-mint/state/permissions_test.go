@@ -224,6 +224,10 @@ func TestSendFails(t *testing.T) {
 	}
 }
 
+// TestName checks the Name permission gate on NameTx plus the NameReg entry
+// lifecycle it controls: only the owner may update an entry before it
+// expires, a non-owner is rejected even with Name permission, and once an
+// entry expires any permissioned account may claim (renew) it.
 func TestName(t *testing.T) {
 	stateDB := dbm.NewDB("state", dbBackend, dbDir)
 	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
@@ -236,7 +240,7 @@ func TestName(t *testing.T) {
 	// name txs
 
 	// simple name tx without perm should fail
-	tx, err := txs.NewNameTx(st, user[0].PubKey, "somename", "somedata", 10000, 100)
+	tx, err := txs.NewNameTx(blockCache, user[0].PubKey, "somename", "somedata", 10000, 100)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -248,7 +252,8 @@ func TestName(t *testing.T) {
 	}
 
 	// simple name tx with perm should pass
-	tx, err = txs.NewNameTx(st, user[1].PubKey, "somename", "somedata", 10000, 100)
+	balanceBefore := blockCache.GetAccount(user[1].Address).Balance
+	tx, err = txs.NewNameTx(blockCache, user[1].PubKey, "somename", "somedata", 10000, 100)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -256,14 +261,94 @@ func TestName(t *testing.T) {
 	if err := ExecTx(blockCache, tx, true, nil); err != nil {
 		t.Fatal(err)
 	}
+	entry := blockCache.GetNameRegEntry("somename")
+	if entry == nil {
+		t.Fatal("expected somename to be registered")
+	}
+	if !bytes.Equal(entry.Owner, user[1].Address) {
+		t.Fatalf("expected somename to be owned by user[1], got %X", entry.Owner)
+	}
+
+	// registering debits exactly tx.Amount + tx.Fee; tx.Amount must cover
+	// NameRegEntryCost, which is proportional to len(Name)+len(Data) and the
+	// entry's lifetime
+	balanceAfter := blockCache.GetAccount(user[1].Address).Balance
+	if balanceBefore-balanceAfter != tx.Amount+tx.Fee {
+		t.Fatalf("expected balance to be debited by Amount+Fee (%d), got %d", tx.Amount+tx.Fee, balanceBefore-balanceAfter)
+	}
+
+	// a NameTx that doesn't cover the name-storage fee should fail
+	cost := NameRegEntryCost("anothername", "anotherdata")
+	tx, err = txs.NewNameTx(blockCache, user[1].PubKey, "anothername", "anotherdata", cost-1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Sign(chainID, user[1])
+	if err := ExecTx(blockCache, tx, true, nil); err == nil {
+		t.Fatal("Expected error: Amount below NameRegEntryCost should be rejected")
+	} else {
+		fmt.Println(err)
+	}
+
+	// the owner may update their own entry before it expires
+	tx, err = txs.NewNameTx(blockCache, user[1].PubKey, "somename", "newdata", 10000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Sign(chainID, user[1])
+	if err := ExecTx(blockCache, tx, true, nil); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	entry = blockCache.GetNameRegEntry("somename")
+	if entry.Data != "newdata" {
+		t.Fatalf("expected owner update to take effect, got %q", entry.Data)
+	}
+
+	// a non-owner may not overwrite the entry before it expires, even with Name permission
+	acc := blockCache.GetAccount(user[2].Address)
+	acc.Permissions.Base.Set(ptypes.Name, true)
+	blockCache.UpdateAccount(acc)
+	tx, err = txs.NewNameTx(blockCache, user[2].PubKey, "somename", "stolendata", 10000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Sign(chainID, user[2])
+	if err := ExecTx(blockCache, tx, true, nil); err == nil {
+		t.Fatal("Expected error: non-owner should not be able to overwrite an unexpired entry")
+	} else {
+		fmt.Println(err)
+	}
+
+	// once the entry expires, any permissioned account may claim (renew) it
+	entry = blockCache.GetNameRegEntry("somename")
+	entry.Expires = 0
+	blockCache.UpdateNameRegEntry(entry)
+	tx, err = txs.NewNameTx(blockCache, user[2].PubKey, "somename", "renewed", 10000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Sign(chainID, user[2])
+	if err := ExecTx(blockCache, tx, true, nil); err != nil {
+		t.Fatal("Unexpected error renewing expired entry", err)
+	}
+	entry = blockCache.GetNameRegEntry("somename")
+	if !bytes.Equal(entry.Owner, user[2].Address) {
+		t.Fatalf("expected renewed entry to be owned by user[2], got %X", entry.Owner)
+	}
 }
 
+// TestCallFails checks that a CallTx is rejected when its signer holds no
+// base permission or only one other than the one it needs, both calling an
+// existing contract and calling with nil Address (contract creation),
+// including that CreateAccount alone does not let a CallTx-create through:
+// that requires CreateContract.
 func TestCallFails(t *testing.T) {
 	stateDB := dbm.NewDB("state", dbBackend, dbDir)
 	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
 	genDoc.Accounts[1].Permissions.Base.Set(ptypes.Send, true)
 	genDoc.Accounts[2].Permissions.Base.Set(ptypes.Call, true)
 	genDoc.Accounts[3].Permissions.Base.Set(ptypes.CreateContract, true)
+	genDoc.Accounts[4].Permissions.Base.Set(ptypes.CreateAccount, true)
 	st := MakeGenesisState(stateDB, &genDoc)
 	blockCache := NewBlockCache(st)
 
@@ -326,6 +411,15 @@ func TestCallFails(t *testing.T) {
 	} else {
 		fmt.Println(err)
 	}
+
+	// simple call create tx with create_account perm should fail (create_account does not imply create_contract)
+	tx, _ = txs.NewCallTx(blockCache, user[4].PubKey, nil, nil, 100, 100, 100)
+	tx.Sign(chainID, user[4])
+	if err := ExecTx(blockCache, tx, true, nil); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		fmt.Println(err)
+	}
 }
 
 func TestSendPermission(t *testing.T) {
@@ -421,6 +515,24 @@ func TestCallPermission(t *testing.T) {
 		t.Fatal("Expected exception")
 	}
 
+	//----------------------------------------------------------
+	// even though caller1Contract's own frame reverts for lack of Call permission,
+	// the inner attempt on simpleContractAddr must still be observable via AccReceive
+	fmt.Println("\n##### CALL TO SIMPLE CONTRACT (AccReceive fires on the reverted inner frame)")
+
+	txReceive, _ := txs.NewCallTx(blockCache, user[0].PubKey, caller1ContractAddr, nil, 100, 10000, 100)
+	txReceive.Sign(chainID, user[0])
+	recvEv, _, txErr := execTxWaitAccReceiveAndCall(t, blockCache, txReceive, simpleContractAddr)
+	if !bytes.Equal(recvEv.Caller, caller1ContractAddr) {
+		t.Fatalf("expected AccReceive caller to be caller1ContractAddr, got %X", recvEv.Caller)
+	}
+	if recvEv.StackDepth != 1 {
+		t.Fatalf("expected AccReceive at StackDepth 1 (one frame below the CallTx), got %d", recvEv.StackDepth)
+	}
+	if txErr == "" {
+		t.Fatal("expected the enclosing CallTx to still fail: caller1Contract never gained Call permission")
+	}
+
 	//----------------------------------------------------------
 	// call to contract that calls simple contract - with perm
 	fmt.Println("\n##### CALL TO SIMPLE CONTRACT (PASS)")
@@ -486,6 +598,173 @@ func TestCallPermission(t *testing.T) {
 	}
 }
 
+// TestPermissionsGetterCallback exercises vm.VM's PermissionsGetter hook (an
+// instance method: ExecTxWithVM's default VM installs one that maps a
+// vm.Account back to its BlockCache account) by installing, on a VM scoped to
+// this one call, a getter that lies about a single address, to prove the VM
+// consults the callback rather than any permissions baked into the
+// account.Account it was handed.
+func TestPermissionsGetterCallback(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.Call, true) // give the 0 account permission
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+
+	// simpleContract <- caller1Contract <- caller2Contract, both callers hold
+	// Call on-chain
+	simpleContractAddr := NewContractAddress(user[0].Address, 100)
+	simpleAcc := &acm.Account{
+		Address:     simpleContractAddr,
+		Balance:     0,
+		Code:        []byte{0x60},
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	blockCache.UpdateAccount(simpleAcc)
+
+	caller1ContractAddr := NewContractAddress(user[0].Address, 101)
+	caller1Acc := &acm.Account{
+		Address:     caller1ContractAddr,
+		Balance:     10000,
+		Code:        callContractCode(simpleContractAddr),
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	caller1Acc.Permissions.Base.Set(ptypes.Call, true)
+	blockCache.UpdateAccount(caller1Acc)
+
+	caller2ContractAddr := NewContractAddress(user[0].Address, 102)
+	caller2Acc := &acm.Account{
+		Address:     caller2ContractAddr,
+		Balance:     1000,
+		Code:        callContractCode(caller1ContractAddr),
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	caller2Acc.Permissions.Base.Set(ptypes.Call, true)
+	blockCache.UpdateAccount(caller2Acc)
+
+	// install, on the VM scoped to this call only, a getter that denies Call
+	// for caller2ContractAddr specifically, regardless of what the BlockCache
+	// account actually says
+	getter := func(acc *vm.Account) ptypes.BasePermissions {
+		perms := blockCache.GetAccount(acc.Address).Permissions.Base
+		if bytes.Equal(acc.Address, caller2ContractAddr) {
+			perms.Set(ptypes.Call, false)
+		}
+		return perms
+	}
+
+	tx, _ := txs.NewCallTx(blockCache, user[0].PubKey, caller2ContractAddr, nil, 100, 10000, 100)
+	tx.Sign(chainID, user[0])
+	// caller2Contract's own CALL is refused, so the exception is on its frame
+	_, exception := execTxWaitEventWithPermissionsGetter(t, blockCache, tx, user[0].Address, txs.EventStringAccCall(caller2ContractAddr), getter)
+	if exception == "" {
+		t.Fatal("Expected exception: PermissionsGetter callback should override the on-chain Call permission")
+	}
+}
+
+// TestPermissionsGetterChain builds a three-contract chain A -> B -> C and proves
+// that every intermediate frame is gated by the PermissionsGetter called against
+// that frame's own callee, not just whatever the outer CallTx was configured with.
+func TestPermissionsGetterChain(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.Call, true) // give the 0 account permission
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+
+	// C itself CALLs the zero address, so C's own Call bit decides whether
+	// the B -> C hop succeeds
+	cAddr := NewContractAddress(user[0].Address, 200)
+	cAcc := &acm.Account{
+		Address:     cAddr,
+		Balance:     0,
+		Code:        callContractCode(LeftPadBytes([]byte{}, 20)),
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	blockCache.UpdateAccount(cAcc)
+
+	bAddr := NewContractAddress(user[0].Address, 201)
+	bAcc := &acm.Account{
+		Address:     bAddr,
+		Balance:     10000,
+		Code:        callContractCode(cAddr),
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	bAcc.Permissions.Base.Set(ptypes.Call, true)
+	blockCache.UpdateAccount(bAcc)
+
+	aAddr := NewContractAddress(user[0].Address, 202)
+	aAcc := &acm.Account{
+		Address:     aAddr,
+		Balance:     10000,
+		Code:        callContractCode(bAddr),
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	aAcc.Permissions.Base.Set(ptypes.Call, true)
+	blockCache.UpdateAccount(aAcc)
+
+	//----------------------------------------------------------
+	// C has no Call permission: the B -> C hop must raise an exception
+	fmt.Println("\n##### CHAIN A->B->C: B->C hop fails, C lacks Call")
+
+	tx, _ := txs.NewCallTx(blockCache, user[0].PubKey, aAddr, nil, 100, 10000, 100)
+	tx.Sign(chainID, user[0])
+	_, exception := execTxWaitEvent(t, blockCache, tx, txs.EventStringAccCall(bAddr))
+	if exception == "" {
+		t.Fatal("Expected exception at the B->C hop")
+	}
+
+	//----------------------------------------------------------
+	// C still has no on-chain Call permission, but a getter installed on the
+	// VM for this call alone grants it Call for this one frame: the whole
+	// chain must now succeed even though blockCache never changed. This is
+	// the part of the test that distinguishes it from a plain on-chain
+	// permission check: every frame's gate is `getter(callee)`, not
+	// `callee.Permissions`, and A's and B's frames must still consult the
+	// same getter rather than whatever the outer CallTx carried.
+	fmt.Println("\n##### CHAIN A->B->C: B->C hop passes once the getter grants C Call for this call only")
+
+	getter := func(acc *vm.Account) ptypes.BasePermissions {
+		perms := blockCache.GetAccount(acc.Address).Permissions.Base
+		if bytes.Equal(acc.Address, cAddr) {
+			perms.Set(ptypes.Call, true)
+		}
+		return perms
+	}
+
+	tx, _ = txs.NewCallTx(blockCache, user[0].PubKey, aAddr, nil, 100, 10000, 100)
+	tx.Sign(chainID, user[0])
+	_, exception = execTxWaitEventWithPermissionsGetter(t, blockCache, tx, user[0].Address, txs.EventStringAccCall(bAddr), getter)
+	if exception != "" {
+		t.Fatal("Unexpected exception", exception)
+	}
+
+	// on-chain permissions are unchanged: a fresh call using the default
+	// getter must still see C as unauthorized, proving the override above
+	// was scoped to that one VM instance and did not leak into blockCache
+	// or any other call's VM.
+	fmt.Println("\n##### CHAIN A->B->C: on-chain state is unaffected by the getter override")
+
+	tx, _ = txs.NewCallTx(blockCache, user[0].PubKey, aAddr, nil, 100, 10000, 100)
+	tx.Sign(chainID, user[0])
+	_, exception = execTxWaitEvent(t, blockCache, tx, txs.EventStringAccCall(bAddr))
+	if exception == "" {
+		t.Fatal("Expected exception at the B->C hop: getter override must not have leaked into on-chain state")
+	}
+}
+
 func TestCreatePermission(t *testing.T) {
 	stateDB := dbm.NewDB("state", dbBackend, dbDir)
 	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
@@ -603,9 +882,200 @@ func TestCreatePermission(t *testing.T) {
 	}
 }
 
-/* TODO
+// TestRootPermission checks that an account holding only the Root permission
+// can perform actions gated by a specific base permission (Send, Call,
+// CreateContract, Name, Bond, and the setBase SNative op via PermissionsTx)
+// despite none of those bits being set, while an account with neither Root
+// nor Send is still rejected for a SendTx.
+func TestRootPermission(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.Root, true) // give the 0 account Root and nothing else
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+
+	//------------------------------
+	// Root should grant Send even though Send is unset
+	fmt.Println("\n##### ROOT: SendTx")
+
+	tx := txs.NewSendTx()
+	if err := tx.AddInput(blockCache, user[0].PubKey, 5); err != nil {
+		t.Fatal(err)
+	}
+	tx.AddOutput(user[6].Address, 5) // user[6] is not a genesis account, exercises CreateAccount too
+	tx.SignInput(chainID, 0, user[0])
+	if err := ExecTx(blockCache, tx, true, nil); err != nil {
+		t.Fatal("Transaction failed", err)
+	}
+
+	//------------------------------
+	// Root should grant Call even though Call is unset
+	fmt.Println("\n##### ROOT: CallTx")
+
+	simpleContractAddr := NewContractAddress(user[0].Address, 100)
+	simpleAcc := &acm.Account{
+		Address:     simpleContractAddr,
+		Balance:     0,
+		Code:        []byte{0x60},
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	blockCache.UpdateAccount(simpleAcc)
+
+	txCall, _ := txs.NewCallTx(blockCache, user[0].PubKey, simpleContractAddr, nil, 100, 100, 100)
+	txCall.Sign(chainID, user[0])
+	if err := ExecTx(blockCache, txCall, true, nil); err != nil {
+		t.Fatal("Transaction failed", err)
+	}
+
+	//------------------------------
+	// Root should grant CreateContract even though CreateContract is unset
+	fmt.Println("\n##### ROOT: CallTx create")
+
+	createCode := wrapContractForCreate([]byte{0x60})
+	txCreate, _ := txs.NewCallTx(blockCache, user[0].PubKey, nil, createCode, 100, 100, 100)
+	txCreate.Sign(chainID, user[0])
+	if err := ExecTx(blockCache, txCreate, true, nil); err != nil {
+		t.Fatal("Transaction failed", err)
+	}
+
+	//------------------------------
+	// Root should grant Name even though Name is unset
+	fmt.Println("\n##### ROOT: NameTx")
+
+	txName, err := txs.NewNameTx(blockCache, user[0].PubKey, "rootname", "rootdata", 10000, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txName.Sign(chainID, user[0])
+	if err := ExecTx(blockCache, txName, true, nil); err != nil {
+		t.Fatal("Transaction failed", err)
+	}
+
+	//------------------------------
+	// Root should grant Bond even though Bond is unset
+	fmt.Println("\n##### ROOT: BondTx")
+
+	txBond, _ := txs.NewBondTx(user[0].PubKey)
+	if err := txBond.AddInput(blockCache, user[0].PubKey, 5); err != nil {
+		t.Fatal(err)
+	}
+	txBond.AddOutput(user[0].Address, 5)
+	txBond.SignInput(chainID, 0, user[0])
+	txBond.SignBond(chainID, user[0])
+	if err := ExecTx(blockCache, txBond, true, nil); err != nil {
+		t.Fatal("Transaction failed", err)
+	}
+
+	//------------------------------
+	// Root should grant an SNative op (setBase) even though SetBase is unset
+	fmt.Println("\n##### ROOT: SNative setBase")
+
+	snativeArgs := snativePermTestInputTx("setBase", user[1], ptypes.Send, true)
+	txSNative, _ := txs.NewPermissionsTx(blockCache, user[0].PubKey, snativeArgs)
+	txSNative.Sign(chainID, user[0])
+	if err := ExecTx(blockCache, txSNative, true, nil); err != nil {
+		t.Fatal("Transaction failed", err)
+	}
+
+	//------------------------------
+	// A user with neither Root nor Send should still fail a SendTx
+	fmt.Println("\n##### ROOT: negative control (user[2])")
+
+	tx = txs.NewSendTx()
+	if err := tx.AddInput(blockCache, user[2].PubKey, 5); err != nil {
+		t.Fatal(err)
+	}
+	tx.AddOutput(user[7].Address, 5)
+	tx.SignInput(chainID, 0, user[2])
+	if err := ExecTx(blockCache, tx, true, nil); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		fmt.Println(err)
+	}
+
+	//----------------------------------------------------------
+	// Granting Root is special: SetBase alone is not enough to set the Root bit,
+	// only an existing Root holder may do so.
+	fmt.Println("\n##### ROOT: SetBase cannot grant Root without Root")
+
+	acc3 := blockCache.GetAccount(user[3].Address)
+	acc3.Permissions.Base.Set(ptypes.SetBase, true)
+	blockCache.UpdateAccount(acc3)
+
+	snativeArgsGrantRoot := snativePermTestInputTx("setBase", user[2], ptypes.Root, true)
+	txGrantRoot, _ := txs.NewPermissionsTx(blockCache, user[3].PubKey, snativeArgsGrantRoot)
+	txGrantRoot.Sign(chainID, user[3])
+	if err := ExecTx(blockCache, txGrantRoot, true, nil); err == nil {
+		t.Fatal("Expected error: a non-Root SetBase holder must not be able to grant Root")
+	} else {
+		fmt.Println(err)
+	}
+	if v, _ := blockCache.GetAccount(user[2].Address).Permissions.Base.Get(ptypes.Root); v {
+		t.Fatal("user[2] must not hold Root after the rejected grant attempt")
+	}
+
+	// the same call succeeds once the caller itself holds Root
+	txGrantRoot, _ = txs.NewPermissionsTx(blockCache, user[0].PubKey, snativeArgsGrantRoot)
+	txGrantRoot.Sign(chainID, user[0])
+	if err := ExecTx(blockCache, txGrantRoot, true, nil); err != nil {
+		t.Fatal("Unexpected error granting Root from a Root holder", err)
+	}
+	acc2 := blockCache.GetAccount(user[2].Address)
+	if v, _ := acc2.Permissions.Base.Get(ptypes.Root); !v {
+		t.Fatal("expected user[2] to have been granted Root")
+	}
+
+	//----------------------------------------------------------
+	// Revoking Root mid-transaction (via an SNative call inside an EVM frame) must
+	// take effect immediately for nested CALLs made later in the same frame tree.
+	fmt.Println("\n##### ROOT: revoked mid-call loses privileges for nested CALL")
+
+	targetAddr := NewContractAddress(user[0].Address, 103)
+	targetAcc := &acm.Account{
+		Address:     targetAddr,
+		Balance:     0,
+		Code:        []byte{0x60},
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	blockCache.UpdateAccount(targetAcc)
+
+	revokerAddr := NewContractAddress(user[0].Address, 104)
+
+	// unsetBase(revokerAddr, Root) - the contract revokes its own Root bit
+	unsetRootData := LeftPadBytes(revokerAddr, 32)
+	unsetRootData = append(unsetRootData, Uint64ToWord256(uint64(ptypes.Root)).Bytes()...)
+	unsetRootData = append(permNameToFuncID("unsetBase"), unsetRootData...)
+
+	revokerAcc := &acm.Account{
+		Address:     revokerAddr,
+		Balance:     10000,
+		Code:        twoStepCallCode(permissionsContract.AddressBytes(), unsetRootData, targetAddr),
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	revokerAcc.Permissions.Base.Set(ptypes.Root, true)
+	blockCache.UpdateAccount(revokerAcc)
+
+	txRevoke, _ := txs.NewCallTx(blockCache, user[0].PubKey, revokerAddr, nil, 100, 10000, 100)
+	txRevoke.Sign(chainID, user[0])
+	// the refused CALL to targetAddr aborts the revoker's own frame
+	_, exception := execTxWaitEvent(t, blockCache, txRevoke, txs.EventStringAccCall(revokerAddr))
+	if exception == "" {
+		t.Fatal("Expected exception: nested CALL should have lost Root privileges after mid-frame revocation")
+	}
+}
+
+// TestBondPermission checks the Bond permission gate on BondTx across its
+// input set: every input funding a bond must itself hold Send or Bond, and
+// the validator being bonded must hold Bond, regardless of how many inputs
+// are involved.
 func TestBondPermission(t *testing.T) {
-	stateDB := dbm.NewDB("state",dbBackend,dbDir)
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
 	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
 	st := MakeGenesisState(stateDB, &genDoc)
 	blockCache := NewBlockCache(st)
@@ -613,6 +1083,7 @@ func TestBondPermission(t *testing.T) {
 
 	//------------------------------
 	// one bonder without permission should fail
+	fmt.Println("\n##### BOND: 1 input, no perm")
 	tx, _ := txs.NewBondTx(user[1].PubKey)
 	if err := tx.AddInput(blockCache, user[1].PubKey, 5); err != nil {
 		t.Fatal(err)
@@ -628,6 +1099,7 @@ func TestBondPermission(t *testing.T) {
 
 	//------------------------------
 	// one bonder with permission should pass
+	fmt.Println("\n##### BOND: 1 input, perm")
 	bondAcc = blockCache.GetAccount(user[1].Address)
 	bondAcc.Permissions.Base.Set(ptypes.Bond, true)
 	blockCache.UpdateAccount(bondAcc)
@@ -643,7 +1115,8 @@ func TestBondPermission(t *testing.T) {
 	bondAcc.Permissions.Base.Set(ptypes.Bond, true)
 	blockCache.UpdateAccount(bondAcc)
 	//------------------------------
-	// one bonder with permission and an input without send should fail
+	// one bonder with permission and an input without send or bond should fail
+	fmt.Println("\n##### BOND: bonder perm, input no perm")
 	tx, _ = txs.NewBondTx(user[1].PubKey)
 	if err := tx.AddInput(blockCache, user[2].PubKey, 5); err != nil {
 		t.Fatal(err)
@@ -666,6 +1139,7 @@ func TestBondPermission(t *testing.T) {
 	blockCache.UpdateAccount(bondAcc)
 	//------------------------------
 	// one bonder with permission and an input with send should pass
+	fmt.Println("\n##### BOND: bonder perm, input send perm")
 	sendAcc := blockCache.GetAccount(user[2].Address)
 	sendAcc.Permissions.Base.Set(ptypes.Send, true)
 	blockCache.UpdateAccount(sendAcc)
@@ -689,6 +1163,7 @@ func TestBondPermission(t *testing.T) {
 	blockCache.UpdateAccount(bondAcc)
 	//------------------------------
 	// one bonder with permission and an input with bond should pass
+	fmt.Println("\n##### BOND: bonder perm, input bond perm")
 	sendAcc.Permissions.Base.Set(ptypes.Bond, true)
 	blockCache.UpdateAccount(sendAcc)
 	tx, _ = txs.NewBondTx(user[1].PubKey)
@@ -711,6 +1186,7 @@ func TestBondPermission(t *testing.T) {
 	blockCache.UpdateAccount(bondAcc)
 	//------------------------------
 	// one bonder with permission and an input from that bonder and an input without send or bond should fail
+	fmt.Println("\n##### BOND: 2 inputs, one with perm one without")
 	tx, _ = txs.NewBondTx(user[1].PubKey)
 	if err := tx.AddInput(blockCache, user[1].PubKey, 5); err != nil {
 		t.Fatal(err)
@@ -726,7 +1202,54 @@ func TestBondPermission(t *testing.T) {
 		t.Fatal("Expected error")
 	}
 }
-*/
+
+// TestUnbondPermission checks that UnbondTx requires the unbonding validator
+// to still hold Bond at unbond time, not just when they originally bonded:
+// losing Bond after bonding blocks the unbond, and regaining it unblocks it.
+func TestUnbondPermission(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[1].Permissions.Base.Set(ptypes.Bond, true)
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+
+	bondTx, _ := txs.NewBondTx(user[1].PubKey)
+	if err := bondTx.AddInput(blockCache, user[1].PubKey, 5); err != nil {
+		t.Fatal(err)
+	}
+	bondTx.AddOutput(user[1].Address, 5)
+	bondTx.SignInput(chainID, 0, user[1])
+	bondTx.SignBond(chainID, user[1])
+	if err := ExecTx(blockCache, bondTx, true, nil); err != nil {
+		t.Fatal("Unexpected error bonding", err)
+	}
+
+	//------------------------------
+	// unbonding without the validator's own Bond permission should fail
+	fmt.Println("\n##### UNBOND: validator lost Bond permission")
+	bondAcc := blockCache.GetAccount(user[1].Address)
+	bondAcc.Permissions.Base.Set(ptypes.Bond, false)
+	blockCache.UpdateAccount(bondAcc)
+	unbondTx := txs.NewUnbondTx(user[1].Address, 1)
+	unbondTx.Sign(chainID, user[1])
+	if err := ExecTx(blockCache, unbondTx, true, nil); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		fmt.Println(err)
+	}
+
+	//------------------------------
+	// unbonding with the validator's own Bond permission should pass and fire the Unbond event
+	fmt.Println("\n##### UNBOND: validator has Bond permission")
+	bondAcc.Permissions.Base.Set(ptypes.Bond, true)
+	blockCache.UpdateAccount(bondAcc)
+	unbondTx = txs.NewUnbondTx(user[1].Address, 1)
+	unbondTx.Sign(chainID, user[1])
+	_, exception := execTxWaitEvent(t, blockCache, unbondTx, txs.EventStringUnbond(user[1].Address))
+	if exception != "" {
+		t.Fatal("Unexpected exception", exception)
+	}
+}
 
 func TestCreateAccountPermission(t *testing.T) {
 	stateDB := dbm.NewDB("state", dbBackend, dbDir)
@@ -871,6 +1394,10 @@ func TestCreateAccountPermission(t *testing.T) {
 // holla at my boy
 var DougAddress = append([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, []byte("THISISDOUG")...)
 
+// TestSNativeCALL exercises every SNative permission/role function via CALL,
+// no-perm-fails then with-perm-passes, finishing with a factory subtest that
+// addRole's then hasRole's user[3] via two chained CALLs inside one CallTx, to
+// check a role mutated mid-tx is visible to a later frame of that same tx.
 func TestSNativeCALL(t *testing.T) {
 	stateDB := dbm.NewDB("state", dbBackend, dbDir)
 	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
@@ -1003,6 +1530,40 @@ func TestSNativeCALL(t *testing.T) {
 		}
 		return nil
 	})
+
+	fmt.Println("\n#### Factory: addRole then hasRole via nested CALLs in a single tx")
+	// a factory contract that adds a role to user[3] and, in the same call tree,
+	// reads it straight back through hasRole - proving roles mutated mid-tx are
+	// visible to later frames of that same tx, not just to subsequent txs
+	_, _, addRoleData := snativeRoleTestInputCALL("addRole", user[3], "klaus")
+	_, _, hasRoleData := snativeRoleTestInputCALL("hasRole", user[3], "klaus")
+
+	factoryAddr := NewContractAddress(user[0].Address, 105)
+	factoryAcc := &acm.Account{
+		Address:     factoryAddr,
+		Balance:     10000,
+		Code:        twoStepCallCode(permissionsContract.AddressBytes(), addRoleData, permissionsContract.AddressBytes()),
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	factoryAcc.Permissions.Base.Set(ptypes.Call, true)
+	factoryAcc.Permissions.Base.Set(ptypes.AddRole, true)
+	factoryAcc.Permissions.Base.Set(ptypes.HasRole, true)
+	blockCache.UpdateAccount(factoryAcc)
+
+	tx, _ := txs.NewCallTx(blockCache, user[0].PubKey, factoryAddr, hasRoleData, 100, 10000, 100)
+	tx.Sign(chainID, user[0])
+	// the Permissions contract is CALLed twice (addRole first), so read the
+	// factory's own return value, which is hasRole's
+	ev, exception := execTxWaitEvent(t, blockCache, tx, txs.EventStringAccCall(factoryAddr))
+	if exception != "" {
+		t.Fatal("Unexpected exception", exception)
+	}
+	ret := ev.(txs.EventDataCall).Return
+	if !IsZeros(ret[:31]) || ret[31] != byte(1) {
+		t.Fatalf("Expected 1. Got %X", ret)
+	}
 }
 
 func TestSNativeTx(t *testing.T) {
@@ -1075,6 +1636,100 @@ func TestSNativeTx(t *testing.T) {
 	}
 }
 
+// TestSNativeEnumeration covers the read-only getBase/getGlobal/listRoles SNative
+// functions and the atomic setBaseBatch mutation via CALL (TestSNativeEnumerationTx
+// covers setBaseBatch via PermissionsTx), in the same no-perm-fails /
+// with-perm-passes style as TestSNativeCALL/TestSNativeTx.
+func TestSNativeEnumeration(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.Call, true) // give the 0 account permission
+	genDoc.Accounts[3].Permissions.Base.Set(ptypes.Bond, true) // some arbitrary permission to play with
+	genDoc.Accounts[3].Permissions.AddRole("bumble")
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+
+	doug := &acm.Account{
+		Address:     DougAddress,
+		Balance:     0,
+		Code:        nil,
+		Sequence:    0,
+		StorageRoot: Zero256.Bytes(),
+		Permissions: ptypes.ZeroAccountPermissions,
+	}
+	doug.Permissions.Base.Set(ptypes.Call, true)
+	blockCache.UpdateAccount(doug)
+
+	fmt.Println("\n#### getBase")
+	snativeAddress, pF, data := snativePermTestInputCALL("getBase", user[3], ptypes.Bond, false)
+	testSNativeCALLExpectFail(t, blockCache, doug, snativeAddress, data)
+	testSNativeCALLExpectPass(t, blockCache, doug, pF, snativeAddress, data, func(ret []byte) error {
+		// (Perms, SetBit) word pair: Bond was explicitly set true
+		if len(ret) != 64 || Word256ToUint64(LeftPadWord256(ret[:32])) != uint64(ptypes.Bond) || Word256ToUint64(LeftPadWord256(ret[32:])) != uint64(ptypes.Bond) {
+			return fmt.Errorf("expected Perms and SetBit words to both be Bond, got %X", ret)
+		}
+		return nil
+	})
+
+	fmt.Println("\n#### getGlobal")
+	snativeAddress, pF, data = snativePermTestInputCALL("getGlobal", user[3], ptypes.Bond, false)
+	testSNativeCALLExpectFail(t, blockCache, doug, snativeAddress, data)
+	testSNativeCALLExpectPass(t, blockCache, doug, pF, snativeAddress, data, func(ret []byte) error { return nil })
+
+	fmt.Println("\n#### listRoles")
+	snativeAddress, pF, data = snativePermTestInputCALL("listRoles", user[3], ptypes.Bond, false)
+	testSNativeCALLExpectFail(t, blockCache, doug, snativeAddress, data)
+	testSNativeCALLExpectPass(t, blockCache, doug, pF, snativeAddress, data, func(ret []byte) error { return nil })
+
+	fmt.Println("\n#### setBaseBatch")
+	// set Send and CreateContract together, atomically, in one call
+	batchMask := uint64(ptypes.Send) | uint64(ptypes.CreateContract)
+	snativeAddress, pF, data = snativeSetBaseBatchTestInputCALL(user[3], batchMask, batchMask)
+	testSNativeCALLExpectFail(t, blockCache, doug, snativeAddress, data)
+	testSNativeCALLExpectPass(t, blockCache, doug, pF, snativeAddress, data, func(ret []byte) error { return nil })
+	acc := blockCache.GetAccount(user[3].Address)
+	if v, _ := acc.Permissions.Base.Get(ptypes.Send); !v {
+		t.Fatal("expected Send to be set by setBaseBatch")
+	}
+	if v, _ := acc.Permissions.Base.Get(ptypes.CreateContract); !v {
+		t.Fatal("expected CreateContract to be set by setBaseBatch")
+	}
+
+	fmt.Println("\n#### setBaseBatch is atomic: an invalid bit aborts the whole batch")
+	before := acc.Permissions.Base
+	// bit 63 does not correspond to any defined PermFlag
+	invalidMask := batchMask | (uint64(1) << 63)
+	doug.Permissions.Base.Set(pF, true)
+	blockCache.UpdateAccount(doug)
+	_, _, data = snativeSetBaseBatchTestInputCALL(user[3], invalidMask, invalidMask)
+	testSNativeCALLExpectFail(t, blockCache, doug, permissionsContract.AddressBytes(), data)
+	acc = blockCache.GetAccount(user[3].Address)
+	if acc.Permissions.Base != before {
+		t.Fatal("expected setBaseBatch to be all-or-nothing: no bits should change when any bit is invalid")
+	}
+}
+
+// TestSNativeEnumerationTx checks the SetBaseBatch permission gate on the
+// PermissionsTx path (as opposed to TestSNativeEnumeration's CALL path),
+// confirming the batch actually lands on the target account.
+func TestSNativeEnumerationTx(t *testing.T) {
+	stateDB := dbm.NewDB("state", dbBackend, dbDir)
+	genDoc := newBaseGenDoc(PermsAllFalse, PermsAllFalse)
+	genDoc.Accounts[0].Permissions.Base.Set(ptypes.Call, true)
+	genDoc.Accounts[3].Permissions.Base.Set(ptypes.Bond, true)
+	st := MakeGenesisState(stateDB, &genDoc)
+	blockCache := NewBlockCache(st)
+
+	fmt.Println("\n#### setBaseBatch via PermissionsTx")
+	snativeArgs := snativeSetBaseBatchTestInputTx(user[3], uint64(ptypes.Send), uint64(ptypes.Send))
+	testSNativeTxExpectFail(t, blockCache, snativeArgs)
+	testSNativeTxExpectPass(t, blockCache, ptypes.SetBaseBatch, snativeArgs)
+	acc := blockCache.GetAccount(user[3].Address)
+	if v, _ := acc.Permissions.Base.Get(ptypes.Send); !v {
+		t.Fatal("expected Send to be set by setBaseBatch")
+	}
+}
+
 //-------------------------------------------------------------------------------------
 // helpers
 
@@ -1116,6 +1771,88 @@ func execTxWaitEvent(t *testing.T, blockCache *BlockCache, tx txs.Tx, eventid st
 	}
 }
 
+// execTxWaitEventWithPermissionsGetter behaves like execTxWaitEvent, except the
+// VM that runs tx is built with getter installed via vm.VM.SetPermissionsGetter,
+// an instance method scoped to the single *vm.VM ExecTxWithVM constructs for this
+// call. It must never be installed as package-level vm state: a package-level
+// getter set by one test would leak into every other test's VM.
+func execTxWaitEventWithPermissionsGetter(t *testing.T, blockCache *BlockCache, tx txs.Tx, callerAddr []byte, eventid string, getter func(acc *vm.Account) ptypes.BasePermissions) (interface{}, string) {
+	evsw := events.NewEventSwitch()
+	evsw.Start()
+	ch := make(chan interface{})
+	evsw.AddListenerForEvent("test", eventid, func(msg events.EventData) {
+		ch <- msg
+	})
+	evc := events.NewEventCache(evsw)
+	theVM := vm.NewVM(blockCache, vm.DefaultDynamicMemoryParams(), callerAddr, tx.Hash(chainID))
+	theVM.SetPermissionsGetter(getter)
+	go func() {
+		if err := ExecTxWithVM(blockCache, tx, true, evc, theVM); err != nil {
+			ch <- err.Error()
+		}
+		evc.Flush()
+	}()
+	ticker := time.NewTicker(5 * time.Second)
+	var msg interface{}
+	select {
+	case msg = <-ch:
+	case <-ticker.C:
+		return nil, ExceptionTimeOut
+	}
+
+	switch ev := msg.(type) {
+	case txs.EventDataTx:
+		return ev, ev.Exception
+	case txs.EventDataCall:
+		return ev, ev.Exception
+	case string:
+		return nil, ev
+	default:
+		return ev, ""
+	}
+}
+
+// run ExecTx and wait for both the pre-call AccReceive and post-call AccCall events on addr.
+// Unlike execTxWaitEvent, AccReceive fires whether or not the enclosing frame later reverts,
+// so this lets callers observe an inner CALL attempt even when the outer CallTx fails.
+func execTxWaitAccReceiveAndCall(t *testing.T, blockCache *BlockCache, tx txs.Tx, addr []byte) (receiveEv, callEv txs.EventDataCall, txErr string) {
+	evsw := events.NewEventSwitch()
+	evsw.Start()
+	chReceive := make(chan interface{}, 1)
+	chCall := make(chan interface{}, 1)
+	evsw.AddListenerForEvent("test-receive", txs.EventStringAccReceive(addr), func(msg events.EventData) {
+		chReceive <- msg
+	})
+	evsw.AddListenerForEvent("test-call", txs.EventStringAccCall(addr), func(msg events.EventData) {
+		chCall <- msg
+	})
+	evc := events.NewEventCache(evsw)
+	done := make(chan error, 1)
+	go func() {
+		done <- ExecTx(blockCache, tx, true, evc)
+		evc.Flush()
+	}()
+
+	ticker := time.NewTicker(5 * time.Second)
+	gotReceive, gotCall := false, false
+	for !gotReceive || !gotCall {
+		select {
+		case msg := <-chReceive:
+			receiveEv = msg.(txs.EventDataCall)
+			gotReceive = true
+		case msg := <-chCall:
+			callEv = msg.(txs.EventDataCall)
+			gotCall = true
+		case <-ticker.C:
+			t.Fatal(ExceptionTimeOut)
+		}
+	}
+	if err := <-done; err != nil {
+		txErr = err.Error()
+	}
+	return
+}
+
 // give a contract perms for an snative, call it, it calls the snative, but shouldn't have permission
 func testSNativeCALLExpectFail(t *testing.T, blockCache *BlockCache, doug *acm.Account, snativeAddress, data []byte) {
 	testSNativeCALL(t, false, blockCache, doug, 0, snativeAddress, data, nil)
@@ -1218,6 +1955,11 @@ func snativePermTestInputCALL(name string, user *acm.PrivAccount, perm ptypes.Pe
 	case "setGlobal":
 		data = Uint64ToWord256(uint64(perm)).Bytes()
 		data = append(data, boolToWord256(val).Bytes()...)
+	case "getBase", "listRoles":
+		// addr only - no perm/value words
+		data = LeftPadBytes(user.Address, 32)
+	case "getGlobal":
+		// no arguments at all
 	}
 	data = append(permNameToFuncID(name), data...)
 	var err error
@@ -1266,6 +2008,59 @@ func snativeRoleTestInputTx(name string, user *acm.PrivAccount, role string) (sn
 	return
 }
 
+// setBaseBatch takes two bitmasks rather than a single (perm, value) pair, so it
+// gets its own CALL-input builder rather than overloading snativePermTestInputCALL.
+func snativeSetBaseBatchTestInputCALL(user *acm.PrivAccount, permsMask, valuesMask uint64) (addr []byte, pF ptypes.PermFlag, data []byte) {
+	addr = permissionsContract.AddressBytes()
+	data = LeftPadBytes(user.Address, 32)
+	data = append(data, Uint64ToWord256(permsMask).Bytes()...)
+	data = append(data, Uint64ToWord256(valuesMask).Bytes()...)
+	data = append(permNameToFuncID("setBaseBatch"), data...)
+
+	var err error
+	if pF, err = ptypes.PermStringToFlag("setBaseBatch"); err != nil {
+		panic("failed to convert perm string (setBaseBatch) to flag")
+	}
+	return
+}
+
+func snativeSetBaseBatchTestInputTx(user *acm.PrivAccount, permsMask, valuesMask uint64) (snativeArgs ptypes.PermArgs) {
+	return &ptypes.SetBaseBatchArgs{user.Address, permsMask, valuesMask}
+}
+
+// convenience function for a contract that first CALLs firstAddr with the fixed
+// calldata firstData (ignoring its return value), then CALLs secondAddr forwarding
+// whatever calldata this contract itself was invoked with. Used to chain two
+// SNative calls in a single frame tree - e.g. mutate a permission or role, then
+// immediately observe that mutation from a second, dependent call.
+func twoStepCallCode(firstAddr, firstData, secondAddr []byte) []byte {
+	value := byte(0x0)
+	retOff, retSize := byte(0x0), byte(0x20)
+
+	// store firstData word-by-word (MSTORE only takes 32 bytes at a time) starting at mem 0
+	var code []byte
+	for wordOff := 0; wordOff < len(firstData); wordOff += 32 {
+		end := wordOff + 32
+		if end > len(firstData) {
+			end = len(firstData)
+		}
+		code = append(code, 0x7f)
+		code = append(code, RightPadWord256(firstData[wordOff:end]).Bytes()...)
+		code = append(code, []byte{0x60, byte(wordOff), 0x52}...)
+	}
+
+	// CALL firstAddr with the stored data, discard the return value
+	code = append(code, Bytecode(
+		PUSH1, retSize, PUSH1, retOff,
+		PUSH1, byte(len(firstData)), PUSH1, 0x0,
+		PUSH1, value, PUSH20, firstAddr,
+		PUSH1, 2, GAS, DIV, CALL, POP)...)
+
+	// then forward this tx's own calldata on to secondAddr, as callContractCode does
+	code = append(code, callContractCode(secondAddr)...)
+	return code
+}
+
 // convenience function for contract that calls a given address
 func callContractCode(contractAddr []byte) []byte {
 	// calldatacopy into mem and use as input to call
@@ -1289,7 +2084,7 @@ func createContractCode() []byte {
 
 	// calldatacopy the calldatasize
 	memOff, inputOff := byte(0x0), byte(0x0)
-	contractCode := []byte{0x60, memOff, 0x60, inputOff, 0x36, 0x37}
+	contractCode := []byte{0x36, 0x60, inputOff, 0x60, memOff, 0x37}
 
 	// create
 	value := byte(0x1)
@@ -0,0 +1,218 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package state is the application state machine: the committed account
+// and name-registry set (State), the per-block read/write overlay over it
+// (BlockCache), and transaction execution against that overlay
+// (execution.go).
+package state
+
+import (
+	acm "github.com/monax/burrow/account"
+	"github.com/monax/burrow/genesis"
+	"github.com/monax/burrow/manager/burrow-mint/evm"
+	ptypes "github.com/monax/burrow/permission/types"
+	. "github.com/monax/burrow/word256"
+
+	dbm "github.com/tendermint/go-db"
+)
+
+// NameRegEntry is a single NameTx-registered (Name -> Data) mapping, owned
+// by Owner until Expires.
+type NameRegEntry struct {
+	Name    string
+	Owner   []byte
+	Data    string
+	Expires int
+}
+
+func (entry *NameRegEntry) Copy() *NameRegEntry {
+	entryCopy := *entry
+	return &entryCopy
+}
+
+// NameRegEntryLifetime is the Expires value a NameReg entry is given each
+// time its owner registers or renews it, and the number of blocks
+// NameRegEntryCost charges storage for. execNameTx treats an entry with
+// Expires > 0 as unexpired; nothing in this package decrements Expires or
+// compares it against a block height, so an entry stays its owner's until
+// its Expires is set to 0.
+const NameRegEntryLifetime = 34
+
+// NameRegEntryCost is the NameTx.Amount a NameTx registering or renewing
+// (name, data) must provide at minimum: a storage fee proportional to the
+// bytes being stored and to how long they'll occupy the NameReg for.
+func NameRegEntryCost(name, data string) int64 {
+	return int64(len(name)+len(data)) * NameRegEntryLifetime
+}
+
+// State is the chain's committed application state: every account and
+// every NameReg entry as of the last committed block.
+type State struct {
+	db       dbm.DB
+	chainID  string
+	accounts map[string]*acm.Account
+	nameReg  map[string]*NameRegEntry
+}
+
+// MakeGenesisState builds the State a chain starts from: every
+// genesis.GenesisAccount becomes an account with its declared permissions,
+// every genesis.GenesisValidator whose address is not already a genesis
+// account becomes an account holding its bonded stake (an existing account
+// is left as it is), and genDoc.Params.GlobalPermissions seeds the reserved
+// GlobalPermissionsAddress account that ungated bits fall back to.
+func MakeGenesisState(db dbm.DB, genDoc *genesis.GenesisDoc) *State {
+	st := &State{
+		db:       db,
+		chainID:  genDoc.ChainID,
+		accounts: make(map[string]*acm.Account),
+		nameReg:  make(map[string]*NameRegEntry),
+	}
+	for _, ga := range genDoc.Accounts {
+		perms := ptypes.ZeroAccountPermissions
+		if ga.Permissions != nil {
+			perms = *ga.Permissions
+		}
+		st.accounts[string(ga.Address)] = &acm.Account{
+			Address:     ga.Address,
+			Balance:     ga.Amount,
+			Permissions: perms,
+		}
+	}
+	if genDoc.Params != nil && genDoc.Params.GlobalPermissions != nil {
+		st.accounts[string(ptypes.GlobalPermissionsAddress)] = &acm.Account{
+			Address:     ptypes.GlobalPermissionsAddress,
+			Permissions: *genDoc.Params.GlobalPermissions,
+		}
+	}
+	for _, gv := range genDoc.Validators {
+		addr := gv.PubKey.Address()
+		if _, ok := st.accounts[string(addr)]; !ok {
+			st.accounts[string(addr)] = &acm.Account{
+				Address:     addr,
+				Balance:     gv.Amount,
+				Permissions: ptypes.ZeroAccountPermissions,
+			}
+		}
+	}
+	return st
+}
+
+// ChainID is the chain this State was genesis'd for - what every Tx's
+// signature and hash are computed over.
+func (st *State) ChainID() string {
+	return st.chainID
+}
+
+func (st *State) GetAccount(addr []byte) *acm.Account {
+	acc, ok := st.accounts[string(addr)]
+	if !ok {
+		return nil
+	}
+	return acc.Copy()
+}
+
+func (st *State) UpdateAccount(acc *acm.Account) {
+	st.accounts[string(acc.Address)] = acc.Copy()
+}
+
+func (st *State) GetNameRegEntry(name string) *NameRegEntry {
+	entry, ok := st.nameReg[name]
+	if !ok {
+		return nil
+	}
+	return entry.Copy()
+}
+
+func (st *State) UpdateNameRegEntry(entry *NameRegEntry) {
+	st.nameReg[entry.Name] = entry.Copy()
+}
+
+// BlockCache is a copy-on-write overlay over a State: reads fall through
+// to the underlying State on a miss, writes land only in the overlay until
+// something commits it back (which this test-oriented package never
+// needs to do).
+type BlockCache struct {
+	st       *State
+	accounts map[string]*acm.Account
+	nameReg  map[string]*NameRegEntry
+	storage  map[string]word256Storage
+}
+
+type word256Storage map[[32]byte][32]byte
+
+func NewBlockCache(st *State) *BlockCache {
+	return &BlockCache{
+		st:       st,
+		accounts: make(map[string]*acm.Account),
+		nameReg:  make(map[string]*NameRegEntry),
+		storage:  make(map[string]word256Storage),
+	}
+}
+
+// ChainID delegates to the underlying State, since a BlockCache never
+// overrides it.
+func (bc *BlockCache) ChainID() string {
+	return bc.st.ChainID()
+}
+
+func (bc *BlockCache) GetAccount(addr []byte) *acm.Account {
+	if acc, ok := bc.accounts[string(addr)]; ok {
+		return acc
+	}
+	return bc.st.GetAccount(addr)
+}
+
+func (bc *BlockCache) UpdateAccount(acc *acm.Account) {
+	bc.accounts[string(acc.Address)] = acc
+}
+
+func (bc *BlockCache) GetNameRegEntry(name string) *NameRegEntry {
+	if entry, ok := bc.nameReg[name]; ok {
+		return entry
+	}
+	return bc.st.GetNameRegEntry(name)
+}
+
+func (bc *BlockCache) UpdateNameRegEntry(entry *NameRegEntry) {
+	bc.nameReg[entry.Name] = entry
+}
+
+// GetStorage and SetStorage, together with the GetAccount/UpdateAccount
+// pair above, make *BlockCache satisfy vm.AppState directly: vm.Account is
+// an alias for acm.Account (see vm.Account), so no conversion layer is
+// needed at the state/VM boundary.
+func (bc *BlockCache) GetStorage(addr []byte, key Word256) Word256 {
+	store, ok := bc.storage[string(addr)]
+	if !ok {
+		return Word256{}
+	}
+	return Word256(store[key])
+}
+
+func (bc *BlockCache) SetStorage(addr []byte, key, value Word256) {
+	store, ok := bc.storage[string(addr)]
+	if !ok {
+		store = make(word256Storage)
+		bc.storage[string(addr)] = store
+	}
+	store[key] = [32]byte(value)
+}
+
+// NewContractAddress deterministically derives the address a CallTx with a
+// nil Address (or a CREATE opcode) assigns to the contract it creates,
+// delegating to the VM's own derivation so both creation paths agree.
+func NewContractAddress(addr []byte, sequence int) []byte {
+	return vm.NewContractAddress(addr, sequence)
+}
@@ -0,0 +1,264 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package types defines the base permission bitmask, the role list, and the
+// argument types for the permission-mutating SNative functions and
+// PermissionsTx.
+package types
+
+import "fmt"
+
+// PermFlag is a bit in the BasePermissions mask. Each permission-gated
+// transaction type or SNative function is gated by one bit; some SNative
+// functions share a bit (see PermStringToFlag).
+type PermFlag uint64
+
+// GlobalPermissionsAddress is the reserved account address whose
+// BasePermissions hold the chain-wide default permissions: an account
+// without an explicit bit set for a given PermFlag falls back to this
+// address's value for that bit.
+var GlobalPermissionsAddress = make([]byte, 20)
+
+const (
+	Root PermFlag = 1 << iota
+	Send
+	Call
+	CreateContract
+	CreateAccount
+	Bond
+	Name
+	HasBase
+	SetBase
+	UnsetBase
+	SetGlobal
+	HasRole
+	AddRole
+	RmRole
+	SetBaseBatch
+
+	TopPermFlag = SetBaseBatch
+	AllPermFlags = TopPermFlag<<1 - 1
+)
+
+var permNameToFlag = map[string]PermFlag{
+	"root":          Root,
+	"send":          Send,
+	"call":          Call,
+	"createContract": CreateContract,
+	"createAccount": CreateAccount,
+	"bond":          Bond,
+	"name":          Name,
+	"hasBase":       HasBase,
+	"setBase":       SetBase,
+	"unsetBase":     UnsetBase,
+	"setGlobal":     SetGlobal,
+	"hasRole":       HasRole,
+	"addRole":       AddRole,
+	"removeRole":    RmRole,
+	"setBaseBatch":  SetBaseBatch,
+	"getBase":       HasBase,
+	"getGlobal":     SetGlobal,
+	"listRoles":     HasRole,
+}
+
+// PermStringToFlag maps an SNative function name (as used in the function
+// table and the test helpers) to the PermFlag that gates it. The read-only
+// enumeration functions have no bit of their own: getBase is gated by
+// HasBase, getGlobal by SetGlobal, and listRoles by HasRole.
+func PermStringToFlag(name string) (PermFlag, error) {
+	flag, ok := permNameToFlag[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown permission name: %s", name)
+	}
+	return flag, nil
+}
+
+// BasePermissions is a (mask, values) bit-pair: mask records which
+// PermFlags have been explicitly set on the owning account, values records
+// what they were set to. A bit absent from mask defers to
+// GlobalPermissions.
+type BasePermissions struct {
+	Perms  PermFlag
+	SetBit PermFlag
+}
+
+// Get returns the explicit value of perm on this BasePermissions and
+// whether it was explicitly set at all. Holding Root short-circuits every
+// other permission: an account with Root explicitly set to true is treated
+// as holding every other base permission too, whether or not that bit was
+// ever individually set.
+func (p BasePermissions) Get(perm PermFlag) (bool, error) {
+	if perm > TopPermFlag {
+		return false, fmt.Errorf("unknown permission flag: %b", perm)
+	}
+	if perm != Root && p.SetBit&Root != 0 && p.Perms&Root != 0 {
+		return true, nil
+	}
+	if p.SetBit&perm == 0 {
+		return false, fmt.Errorf("permission %b is not set", perm)
+	}
+	return p.Perms&perm != 0, nil
+}
+
+// Set assigns value to perm, marking it explicitly set.
+func (p *BasePermissions) Set(perm PermFlag, value bool) error {
+	if perm > TopPermFlag {
+		return fmt.Errorf("unknown permission flag: %b", perm)
+	}
+	p.SetBit |= perm
+	if value {
+		p.Perms |= perm
+	} else {
+		p.Perms &= ^perm
+	}
+	return nil
+}
+
+// Unset clears perm's explicit bit, so it once again defers to
+// GlobalPermissions.
+func (p *BasePermissions) Unset(perm PermFlag) error {
+	if perm > TopPermFlag {
+		return fmt.Errorf("unknown permission flag: %b", perm)
+	}
+	p.SetBit &= ^perm
+	return nil
+}
+
+// IsSet reports whether perm has been explicitly set on this
+// BasePermissions.
+func (p BasePermissions) IsSet(perm PermFlag) bool {
+	return p.SetBit&perm != 0
+}
+
+// SetBatch atomically applies every bit named in permsMask to the
+// corresponding value in valuesMask: if any bit in permsMask does not
+// correspond to a defined PermFlag, no bits are changed at all.
+func (p *BasePermissions) SetBatch(permsMask, valuesMask uint64) error {
+	if permsMask&^uint64(AllPermFlags) != 0 {
+		return fmt.Errorf("setBaseBatch: permsMask contains bits outside the defined PermFlag range: %b", permsMask)
+	}
+	mask := PermFlag(permsMask)
+	values := PermFlag(valuesMask)
+	p.SetBit |= mask
+	p.Perms = (p.Perms &^ mask) | (values & mask)
+	return nil
+}
+
+// AccountPermissions is the full permission state of an account: its base
+// bitmask plus an arbitrary list of named roles.
+type AccountPermissions struct {
+	Base  BasePermissions
+	Roles [][]byte
+}
+
+var ZeroAccountPermissions = AccountPermissions{}
+
+// HasRole reports whether role has been granted to this account.
+func (ap *AccountPermissions) HasRole(role string) bool {
+	for _, r := range ap.Roles {
+		if string(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRole grants role to this account, reporting whether it was newly
+// added (false if the account already held it).
+func (ap *AccountPermissions) AddRole(role string) bool {
+	if ap.HasRole(role) {
+		return false
+	}
+	ap.Roles = append(ap.Roles, []byte(role))
+	return true
+}
+
+// RmRole revokes role from this account, reporting whether it was present
+// to remove.
+func (ap *AccountPermissions) RmRole(role string) bool {
+	for i, r := range ap.Roles {
+		if string(r) == role {
+			ap.Roles = append(ap.Roles[:i], ap.Roles[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// PermArgs is implemented by the argument type of every permission-mutating
+// SNative function, so a single PermissionsTx can carry any of them.
+type PermArgs interface {
+	PermFlag() PermFlag
+}
+
+type HasBaseArgs struct {
+	Address    []byte
+	Permission PermFlag
+}
+
+func (*HasBaseArgs) PermFlag() PermFlag { return HasBase }
+
+type SetBaseArgs struct {
+	Address    []byte
+	Permission PermFlag
+	Value      bool
+}
+
+func (*SetBaseArgs) PermFlag() PermFlag { return SetBase }
+
+type UnsetBaseArgs struct {
+	Address    []byte
+	Permission PermFlag
+}
+
+func (*UnsetBaseArgs) PermFlag() PermFlag { return UnsetBase }
+
+type SetGlobalArgs struct {
+	Permission PermFlag
+	Value      bool
+}
+
+func (*SetGlobalArgs) PermFlag() PermFlag { return SetGlobal }
+
+type HasRoleArgs struct {
+	Address []byte
+	Role    string
+}
+
+func (*HasRoleArgs) PermFlag() PermFlag { return HasRole }
+
+type AddRoleArgs struct {
+	Address []byte
+	Role    string
+}
+
+func (*AddRoleArgs) PermFlag() PermFlag { return AddRole }
+
+type RmRoleArgs struct {
+	Address []byte
+	Role    string
+}
+
+func (*RmRoleArgs) PermFlag() PermFlag { return RmRole }
+
+// SetBaseBatchArgs atomically sets every bit named in PermsMask to the
+// corresponding bit of ValuesMask: if any bit in PermsMask is not a defined
+// PermFlag, none of them are applied.
+type SetBaseBatchArgs struct {
+	Address    []byte
+	PermsMask  uint64
+	ValuesMask uint64
+}
+
+func (*SetBaseBatchArgs) PermFlag() PermFlag { return SetBaseBatch }
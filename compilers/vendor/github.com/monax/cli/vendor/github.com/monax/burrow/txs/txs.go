@@ -0,0 +1,346 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package txs defines every transaction type the chain accepts, the event
+// topics and payloads fired while executing them, and the shared
+// input/output bookkeeping (sequence numbers, signatures) SendTx and
+// BondTx both need.
+package txs
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	acm "github.com/monax/burrow/account"
+	ptypes "github.com/monax/burrow/permission/types"
+
+	"github.com/tendermint/go-crypto"
+)
+
+// AccountGetter is the minimal account lookup a Tx constructor needs to
+// stamp the right sequence number on a new input - satisfied structurally
+// by both state.State and state.BlockCache without either package needing
+// to import txs.
+type AccountGetter interface {
+	GetAccount(addr []byte) *acm.Account
+}
+
+// Tx is implemented by every transaction type. Hash is what gets signed
+// (over chainID) and what events are keyed against.
+type Tx interface {
+	Hash(chainID string) []byte
+}
+
+type TxInput struct {
+	Address   []byte
+	PubKey    crypto.PubKey
+	Amount    int64
+	Sequence  int
+	Signature crypto.Signature
+}
+
+type TxOutput struct {
+	Address []byte
+	Amount  int64
+}
+
+func hashOf(chainID string, parts ...interface{}) []byte {
+	h := sha256.New()
+	h.Write([]byte(chainID))
+	fmt.Fprintf(h, "%v", parts)
+	return h.Sum(nil)
+}
+
+func nextSequence(getter AccountGetter, addr []byte) int {
+	acc := getter.GetAccount(addr)
+	if acc == nil {
+		return 1
+	}
+	return acc.Sequence + 1
+}
+
+//-------------------------------------------------------------------------------------
+// SendTx
+
+type SendTx struct {
+	Inputs  []*TxInput
+	Outputs []*TxOutput
+}
+
+func NewSendTx() *SendTx {
+	return &SendTx{}
+}
+
+func (tx *SendTx) AddInput(getter AccountGetter, pubKey crypto.PubKey, amount int64) error {
+	addr := pubKey.Address()
+	if getter.GetAccount(addr) == nil {
+		return fmt.Errorf("invalid address %X from pubkey %v", addr, pubKey)
+	}
+	tx.Inputs = append(tx.Inputs, &TxInput{
+		Address:  addr,
+		PubKey:   pubKey,
+		Amount:   amount,
+		Sequence: nextSequence(getter, addr),
+	})
+	return nil
+}
+
+func (tx *SendTx) AddOutput(addr []byte, amount int64) {
+	tx.Outputs = append(tx.Outputs, &TxOutput{Address: addr, Amount: amount})
+}
+
+func (tx *SendTx) SignInput(chainID string, idx int, priv *acm.PrivAccount) {
+	tx.Inputs[idx].Signature = priv.Sign(tx.Hash(chainID))
+}
+
+func (tx *SendTx) Hash(chainID string) []byte {
+	return hashOf(chainID, "SendTx", tx.Inputs, tx.Outputs)
+}
+
+//-------------------------------------------------------------------------------------
+// CallTx
+
+// CallTx both calls an existing contract (Address non-nil) and creates a
+// new one (Address nil, in which case Data is the contract's init code).
+type CallTx struct {
+	Input    *TxInput
+	Address  []byte
+	GasLimit int64
+	Fee      int64
+	Data     []byte
+}
+
+func NewCallTx(getter AccountGetter, pubKey crypto.PubKey, address, data []byte, amount, gasLimit, fee int64) (*CallTx, error) {
+	addr := pubKey.Address()
+	if getter.GetAccount(addr) == nil {
+		return nil, fmt.Errorf("invalid address %X from pubkey %v", addr, pubKey)
+	}
+	return &CallTx{
+		Input: &TxInput{
+			Address:  addr,
+			PubKey:   pubKey,
+			Amount:   amount,
+			Sequence: nextSequence(getter, addr),
+		},
+		Address:  address,
+		GasLimit: gasLimit,
+		Fee:      fee,
+		Data:     data,
+	}, nil
+}
+
+func (tx *CallTx) Sign(chainID string, priv *acm.PrivAccount) {
+	tx.Input.Signature = priv.Sign(tx.Hash(chainID))
+}
+
+func (tx *CallTx) Hash(chainID string) []byte {
+	return hashOf(chainID, "CallTx", tx.Input, tx.Address, tx.Data, tx.GasLimit, tx.Fee)
+}
+
+//-------------------------------------------------------------------------------------
+// NameTx
+
+// NameTx registers or updates a NameReg entry: Name -> Data, owned by the
+// signer, for as long as the entry has not expired (see
+// state.BlockCache.UpdateNameRegEntry).
+type NameTx struct {
+	Input  *TxInput
+	Name   string
+	Data   string
+	Amount int64
+	Fee    int64
+}
+
+func NewNameTx(getter AccountGetter, pubKey crypto.PubKey, name, data string, amount, fee int64) (*NameTx, error) {
+	addr := pubKey.Address()
+	if getter.GetAccount(addr) == nil {
+		return nil, fmt.Errorf("invalid address %X from pubkey %v", addr, pubKey)
+	}
+	return &NameTx{
+		Input: &TxInput{
+			Address:  addr,
+			PubKey:   pubKey,
+			Amount:   amount,
+			Sequence: nextSequence(getter, addr),
+		},
+		Name:   name,
+		Data:   data,
+		Amount: amount,
+		Fee:    fee,
+	}, nil
+}
+
+func (tx *NameTx) Sign(chainID string, priv *acm.PrivAccount) {
+	tx.Input.Signature = priv.Sign(tx.Hash(chainID))
+}
+
+func (tx *NameTx) Hash(chainID string) []byte {
+	return hashOf(chainID, "NameTx", tx.Input, tx.Name, tx.Data, tx.Fee)
+}
+
+//-------------------------------------------------------------------------------------
+// BondTx / UnbondTx
+
+type BondTx struct {
+	PubKey   crypto.PubKeyEd25519
+	Inputs   []*TxInput
+	Outputs  []*TxOutput
+	bondSig  crypto.Signature
+	bondSigd bool
+}
+
+func NewBondTx(pubKey crypto.PubKey) (*BondTx, error) {
+	edKey, ok := pubKey.(crypto.PubKeyEd25519)
+	if !ok {
+		return nil, fmt.Errorf("BondTx requires an Ed25519 validator key")
+	}
+	return &BondTx{PubKey: edKey}, nil
+}
+
+func (tx *BondTx) AddInput(getter AccountGetter, pubKey crypto.PubKey, amount int64) error {
+	addr := pubKey.Address()
+	if getter.GetAccount(addr) == nil {
+		return fmt.Errorf("invalid address %X from pubkey %v", addr, pubKey)
+	}
+	tx.Inputs = append(tx.Inputs, &TxInput{
+		Address:  addr,
+		PubKey:   pubKey,
+		Amount:   amount,
+		Sequence: nextSequence(getter, addr),
+	})
+	return nil
+}
+
+func (tx *BondTx) AddOutput(addr []byte, amount int64) {
+	tx.Outputs = append(tx.Outputs, &TxOutput{Address: addr, Amount: amount})
+}
+
+func (tx *BondTx) SignInput(chainID string, idx int, priv *acm.PrivAccount) {
+	tx.Inputs[idx].Signature = priv.Sign(tx.Hash(chainID))
+}
+
+// SignBond signs over the BondTx with the validator's own key, proving the
+// validator itself consents to bonding (separately from whichever account
+// signed the inputs that fund it).
+func (tx *BondTx) SignBond(chainID string, priv *acm.PrivAccount) {
+	tx.bondSig = priv.Sign(tx.Hash(chainID))
+	tx.bondSigd = true
+}
+
+func (tx *BondTx) Hash(chainID string) []byte {
+	return hashOf(chainID, "BondTx", tx.PubKey, tx.Inputs, tx.Outputs)
+}
+
+// VerifyBond reports whether this BondTx carries a valid bond signature -
+// the one SignBond produces - proving the validator itself consented to
+// being bonded, separately from whichever account's signatures fund it.
+func (tx *BondTx) VerifyBond(chainID string) bool {
+	return tx.bondSigd && tx.PubKey.VerifyBytes(tx.Hash(chainID), tx.bondSig)
+}
+
+// UnbondTx releases a validator's bonded stake as of Height. Only the
+// validator's own Bond permission (held at execution time, not bond time)
+// authorizes it - see state.ExecTx.
+type UnbondTx struct {
+	Address []byte
+	Height  int
+	Sig     crypto.Signature
+}
+
+func NewUnbondTx(addr []byte, height int) *UnbondTx {
+	return &UnbondTx{Address: addr, Height: height}
+}
+
+func (tx *UnbondTx) Sign(chainID string, priv *acm.PrivAccount) {
+	tx.Sig = priv.Sign(tx.Hash(chainID))
+}
+
+func (tx *UnbondTx) Hash(chainID string) []byte {
+	return hashOf(chainID, "UnbondTx", tx.Address, tx.Height)
+}
+
+//-------------------------------------------------------------------------------------
+// PermissionsTx
+
+// PermissionsTx carries one ptypes.PermArgs - a gated mutation of some
+// account's (or the chain's) permissions or roles.
+type PermissionsTx struct {
+	Input    *TxInput
+	PermArgs ptypes.PermArgs
+}
+
+func NewPermissionsTx(getter AccountGetter, pubKey crypto.PubKey, args ptypes.PermArgs) (*PermissionsTx, error) {
+	addr := pubKey.Address()
+	if getter.GetAccount(addr) == nil {
+		return nil, fmt.Errorf("invalid address %X from pubkey %v", addr, pubKey)
+	}
+	return &PermissionsTx{
+		Input: &TxInput{
+			Address:  addr,
+			PubKey:   pubKey,
+			Sequence: nextSequence(getter, addr),
+		},
+		PermArgs: args,
+	}, nil
+}
+
+func (tx *PermissionsTx) Sign(chainID string, priv *acm.PrivAccount) {
+	tx.Input.Signature = priv.Sign(tx.Hash(chainID))
+}
+
+func (tx *PermissionsTx) Hash(chainID string) []byte {
+	return hashOf(chainID, "PermissionsTx", tx.Input, tx.PermArgs)
+}
+
+//-------------------------------------------------------------------------------------
+// Events
+
+func EventStringAccCall(addr []byte) string {
+	return fmt.Sprintf("Acc/%X/Call", addr)
+}
+
+// EventStringAccReceive fires the moment a CALL frame targeting addr
+// begins, before it is known whether that frame (or any enclosing one)
+// will ultimately revert - unlike EventStringAccCall, which fires as the
+// frame exits, carrying its return value and exception.
+func EventStringAccReceive(addr []byte) string {
+	return fmt.Sprintf("Acc/%X/Receive", addr)
+}
+
+func EventStringUnbond(addr []byte) string {
+	return fmt.Sprintf("Unbond/%X", addr)
+}
+
+// EventDataCall is fired for both EventStringAccReceive and
+// EventStringAccCall. StackDepth is the CALL-stack depth (0 for the
+// outermost frame of a CallTx) the frame it describes ran at, so
+// listeners can tell a top-level call from a nested one.
+type EventDataCall struct {
+	Caller     []byte
+	Callee     []byte
+	Data       []byte
+	Return     []byte
+	Exception  string
+	StackDepth int
+}
+
+func (EventDataCall) AssertIsEventData() {}
+
+type EventDataTx struct {
+	Tx        Tx
+	Return    []byte
+	Exception string
+}
+
+func (EventDataTx) AssertIsEventData() {}
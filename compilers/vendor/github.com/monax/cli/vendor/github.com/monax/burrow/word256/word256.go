@@ -0,0 +1,102 @@
+// Copyright 2017 Monax Industries Limited
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package word256 provides the 256-bit (32-byte) word type the EVM operates
+// on, along with the byte-padding helpers used to move values in and out of
+// it.
+package word256
+
+import "encoding/binary"
+
+const Word256Bytes = 32
+
+// Word256 is a 32-byte EVM word, big-endian like the stack and storage it
+// represents.
+type Word256 [Word256Bytes]byte
+
+var Zero256 = Word256{}
+
+func (w Word256) Bytes() []byte {
+	return w[:]
+}
+
+func (w Word256) IsZero() bool {
+	return IsZeros(w[:])
+}
+
+// IsZeros reports whether every byte in bz is zero.
+func IsZeros(bz []byte) bool {
+	for _, b := range bz {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// LeftPadBytes left-pads bz with zeros to n bytes, truncating from the left
+// if it is already longer.
+func LeftPadBytes(bz []byte, n int) []byte {
+	if len(bz) >= n {
+		return bz[len(bz)-n:]
+	}
+	padded := make([]byte, n)
+	copy(padded[n-len(bz):], bz)
+	return padded
+}
+
+// RightPadBytes right-pads bz with zeros to n bytes, truncating from the
+// right if it is already longer.
+func RightPadBytes(bz []byte, n int) []byte {
+	if len(bz) >= n {
+		return bz[:n]
+	}
+	padded := make([]byte, n)
+	copy(padded, bz)
+	return padded
+}
+
+// LeftPadWord256 left-pads bz with zeros to a Word256, truncating from the
+// left if it is already longer than 32 bytes.
+func LeftPadWord256(bz []byte) Word256 {
+	var w Word256
+	if len(bz) > Word256Bytes {
+		bz = bz[len(bz)-Word256Bytes:]
+	}
+	copy(w[Word256Bytes-len(bz):], bz)
+	return w
+}
+
+// RightPadWord256 right-pads bz with zeros to a Word256, truncating from the
+// right if it is already longer than 32 bytes.
+func RightPadWord256(bz []byte) Word256 {
+	var w Word256
+	if len(bz) > Word256Bytes {
+		bz = bz[:Word256Bytes]
+	}
+	copy(w[:], bz)
+	return w
+}
+
+// Uint64ToWord256 encodes i into the low 8 bytes of a big-endian Word256.
+func Uint64ToWord256(i uint64) Word256 {
+	var w Word256
+	binary.BigEndian.PutUint64(w[Word256Bytes-8:], i)
+	return w
+}
+
+// Word256ToUint64 decodes the low 8 bytes of a big-endian Word256.
+func Word256ToUint64(w Word256) uint64 {
+	return binary.BigEndian.Uint64(w[Word256Bytes-8:])
+}